@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+func classifyAddress(ip string) AddressClass {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return classUnknown
+	}
+	addr = addr.Unmap()
+
+	switch {
+	case addr.IsUnspecified():
+		return classUnspecified
+	case addr.IsLoopback():
+		return classLoopback
+	case addr.IsLinkLocalUnicast():
+		return classLinkLocal
+	case addr.IsPrivate() && addr.Is4():
+		return classRFC1918
+	case addr.IsPrivate():
+		return classULA
+	case cgnatPrefix.Contains(addr):
+		return classCGNAT
+	}
+
+	return classPublic
+}
+
+func classifyAddresses(ips []string) map[string]AddressClass {
+	if len(ips) == 0 {
+		return nil
+	}
+
+	classes := map[string]AddressClass{}
+	for _, ip := range ips {
+		classes[ip] = classifyAddress(ip)
+	}
+	return classes
+}
+
+func onlyPublic(classes map[string]AddressClass) bool {
+	if len(classes) == 0 {
+		return false
+	}
+
+	for _, class := range classes {
+		if class != classPublic {
+			return false
+		}
+	}
+	return true
+}
+
+func lookupWarnings(resolver string, failed []Lookup) []string {
+	var (
+		warnings []string
+		seen     = map[string]bool{}
+	)
+
+	name := "system resolver"
+	if resolver != systemResolver[0] {
+		name = "resolver " + resolver
+	}
+
+	for _, lookup := range failed {
+		if seen[lookup.Hostname] {
+			continue
+		}
+
+		switch lookup.Reason {
+		case reasonPublicAddress:
+			warnings = append(warnings, fmt.Sprintf("%s returned a public address for %s (%s) — DNS is bypassing lancache-dns",
+				name, lookup.Hostname, strings.Join(lookup.Address, ", ")))
+		case reasonIPv6Bypass:
+			warnings = append(warnings, fmt.Sprintf("%s returned an AAAA record for %s pointing outside the cache (%s) while the A record points at it — IPv6 clients are bypassing lancache",
+				name, lookup.Hostname, lookup.Error))
+		case reasonPartialCluster:
+			warnings = append(warnings, fmt.Sprintf("%s returned a partially healthy cache cluster for %s (%s)",
+				name, lookup.Hostname, lookup.Error))
+		default:
+			continue
+		}
+		seen[lookup.Hostname] = true
+	}
+
+	return warnings
+}
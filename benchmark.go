@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/progress"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/huh/v2"
+)
+
+func benchmark(opts Options) error {
+	object := opts.BenchURL
+	if object == "" && len(opts.CacheObjects) > 0 {
+		object = opts.CacheObjects[slices.Sorted(maps.Keys(opts.CacheObjects))[0]]
+	}
+	if object == "" {
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewInput().
+				Title("Enter Object URL:").
+				Description("An http:// url served by the cache, ideally a large object that is already cached").
+				Value(&object).
+				Validate(validateBenchURL),
+		)).Run()
+		if err != nil {
+			return fmt.Errorf("prompt failed %w", err)
+		}
+	}
+
+	d, links, discovery := discoverResolvers(opts.Nameservers)
+	policy := opts.ResolverPolicy
+	if policy == "" {
+		policy = policyAll
+	}
+	servers := selectResolvers(policy, d.Servers, opts.Resolvers)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := BenchmarkModel{
+		Progress: progress.New(progress.WithDefaultBlend(), progress.WithWidth(60)),
+		URL:      object,
+		Count:    opts.BenchCount,
+		cancel:   cancel,
+	}
+	p := tea.NewProgram(&m)
+
+	results := make(chan BenchmarkResult, 1)
+	go func() {
+		results <- runBenchmark(ctx, object, servers, opts.BenchCount, opts.Timeouts, func(run int, fraction float64) {
+			p.Send(benchmarkProgressMsg{Run: run, Fraction: fraction})
+		})
+		p.Send(benchmarkDoneMsg{})
+	}()
+
+	if _, err := p.Run(); err != nil {
+		cancel()
+		return err
+	}
+	cancel()
+
+	result := <-results
+
+	var logger io.Writer = os.Stdout
+	if slices.Contains(opts.Formats, formatText) {
+		var logfile *os.File
+		logger, logfile = createTextReport()
+		if logfile != nil {
+			defer closeTextReport(logfile, logger)
+		}
+	}
+	logBenchmark(result, logger)
+
+	if slices.Contains(opts.Formats, formatJSON) {
+		report := Report{
+			SchemaVersion:    reportSchemaVersion,
+			Mode:             opts.Mode,
+			Time:             time.Now().Format(time.RFC3339),
+			Passed:           result.Error == "",
+			Interfaces:       getInterfaceAddresses(io.Discard),
+			Resolvers:        d.Servers,
+			Links:            links,
+			Discovery:        discovery,
+			Search:           d.Search,
+			Ndots:            d.Ndots,
+			ResolverPolicy:   policy,
+			TestedResolvers:  servers,
+			HeartbeatTimeout: milliseconds(opts.Timeouts.Heartbeat),
+			DNSTimeout:       milliseconds(opts.Timeouts.DNS),
+			Benchmark:        &result,
+		}
+		if err := writeJSONReport(jsonReport, report); err != nil {
+			_, _ = fmt.Fprint(logger, fmt.Errorf("error: failed to write json report %w", err))
+		}
+	}
+
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+func validateBenchURL(object string) error {
+	u, err := url.Parse(object)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" || u.Host == "" {
+		return fmt.Errorf("expected an http:// url")
+	}
+	return nil
+}
+
+func runBenchmark(ctx context.Context, object string, servers []string, count int, timeouts Timeouts, report func(run int, fraction float64)) BenchmarkResult {
+	result := BenchmarkResult{URL: object}
+
+	u, err := url.Parse(object)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	resolver, probe, err := resolveCacheAddress(u.Hostname(), servers, timeouts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Resolver, result.Address, result.ContainerID = resolver, probe.Address, probe.ContainerID
+
+	client := cacheClient(u, result.Address, timeouts.Heartbeat)
+	defer client.CloseIdleConnections()
+
+	for i := range count {
+		run, err := benchmarkRun(ctx, client, object, func(fraction float64) {
+			report(i, fraction)
+		})
+		if err != nil {
+			result.Error = err.Error()
+			break
+		}
+		result.Runs = append(result.Runs, run)
+		report(i, 1)
+	}
+
+	if len(result.Runs) == 0 {
+		return result
+	}
+
+	for _, run := range result.Runs {
+		result.Throughput += run.Throughput
+		result.TTFB += run.TTFB
+	}
+	result.Throughput /= float64(len(result.Runs))
+	result.TTFB /= float64(len(result.Runs))
+
+	for _, run := range result.Runs {
+		result.StdDev += math.Pow(run.Throughput-result.Throughput, 2)
+	}
+	result.StdDev = math.Sqrt(result.StdDev / float64(len(result.Runs)))
+
+	return result
+}
+
+func benchmarkRun(ctx context.Context, client *http.Client, object string, report func(fraction float64)) (BenchmarkRun, error) {
+	var (
+		run       BenchmarkRun
+		firstByte time.Time
+	)
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotFirstResponseByte: func() {
+			firstByte = time.Now()
+		},
+	}), http.MethodGet, object, nil)
+	if err != nil {
+		return run, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return run, err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return run, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	run.CacheStatus = strings.ToUpper(resp.Header.Get(cacheStatusHeader))
+
+	buf := make([]byte, 64*1024)
+	reported := time.Now()
+	for {
+		n, err := resp.Body.Read(buf)
+		run.Bytes += int64(n)
+		if resp.ContentLength > 0 && time.Since(reported) > 50*time.Millisecond {
+			report(float64(run.Bytes) / float64(resp.ContentLength))
+			reported = time.Now()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return run, err
+		}
+	}
+
+	elapsed := time.Since(start)
+	run.Duration = milliseconds(elapsed)
+	run.TTFB = milliseconds(firstByte.Sub(start))
+	run.Throughput = float64(run.Bytes) / 1e6 / elapsed.Seconds()
+
+	return run, nil
+}
+
+func logBenchmark(result BenchmarkResult, logger io.Writer) {
+	_, _ = fmt.Fprintf(logger, "Benchmark of %s", result.URL)
+	if result.Address != "" {
+		_, _ = fmt.Fprintf(logger, " from %s (container: %s, resolver: %s)", result.Address, result.ContainerID, result.Resolver)
+	}
+	_, _ = fmt.Fprintf(logger, "\n")
+
+	for i, run := range result.Runs {
+		_, _ = fmt.Fprintf(logger, "Run %d: %.1f MB in %.0f ms, %.1f MB/s, time to first byte %.1f ms, %s\n",
+			i+1, float64(run.Bytes)/1e6, run.Duration, run.Throughput, run.TTFB, statusOrNone(run.CacheStatus))
+	}
+	if len(result.Runs) > 0 {
+		_, _ = fmt.Fprintf(logger, "Average: %.1f MB/s (standard deviation %.1f MB/s), time to first byte %.1f ms\n",
+			result.Throughput, result.StdDev, result.TTFB)
+	}
+}
+
+func (m *BenchmarkModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *BenchmarkModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			m.cancel()
+			m.Quitting = true
+			return m, tea.Quit
+		}
+
+	case benchmarkProgressMsg:
+		m.Run = msg.Run
+		m.Percent = (float64(msg.Run) + min(msg.Fraction, 1)) / float64(m.Count)
+
+	case benchmarkDoneMsg:
+		m.Percent = 1
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+func (m *BenchmarkModel) View() tea.View {
+	theme := huh.ThemeCharm(false)
+	title := theme.Focused.Base.Render() + theme.Focused.Title.Render(diagBenchmark)
+
+	help := theme.Help
+	return tea.NewView(fmt.Sprintf(
+		"%s\n%s\n\n%s\n%s\n\n%s",
+		title,
+		m.URL,
+		m.Progress.ViewAs(m.Percent),
+		help.ShortDesc.Render(fmt.Sprintf("Run %d of %d", min(m.Run+1, m.Count), m.Count)),
+		help.ShortKey.Render("esc")+" "+help.ShortDesc.Render("cancel"),
+	))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+func cacheObjects(cdns []CDNReport, overrides map[string]string) map[string]string {
+	objects := map[string]string{}
+	for name, object := range defaultCacheObjects {
+		for _, cdn := range cdns {
+			if strings.EqualFold(cdn.Name, name) || (cdn.Name == steamDiagnosticsReport && name == Steam.Name) {
+				objects[name] = object
+			}
+		}
+	}
+
+	for cdn, object := range overrides {
+		for name := range defaultCacheObjects {
+			if strings.EqualFold(name, cdn) {
+				cdn = name
+			}
+		}
+		objects[cdn] = object
+	}
+	return objects
+}
+
+func checkCache(objects map[string]string, servers []string, timeouts Timeouts, logger io.Writer) []CacheCheck {
+	_, _ = fmt.Fprintf(logger, "Checking cache effectiveness...\n")
+
+	var checks []CacheCheck
+	for _, cdn := range slices.Sorted(maps.Keys(objects)) {
+		check := fetchCacheObject(cdn, objects[cdn], servers, timeouts)
+		checks = append(checks, check)
+
+		switch {
+		case check.Error != "":
+			_, _ = fmt.Fprintf(logger, "Unable to check cache for %s: %s\n", cdn, check.Error)
+		case check.Effective && check.FirstMiss:
+			_, _ = fmt.Fprintf(logger, "Cache for %s is effective: %s then %s from %s\n", cdn, check.First, check.Second, check.Address)
+		case check.Effective:
+			_, _ = fmt.Fprintf(logger, "Cache for %s is effective, object was already cached: %s then %s from %s\n", cdn, statusOrNone(check.First), check.Second, check.Address)
+		default:
+			_, _ = fmt.Fprintf(logger, "Warning: %s was not served from cache: %s then %s from %s\n", cdn, statusOrNone(check.First), statusOrNone(check.Second), check.Address)
+		}
+	}
+	_, _ = fmt.Fprintf(logger, "\n")
+
+	return checks
+}
+
+func fetchCacheObject(cdn, object string, servers []string, timeouts Timeouts) CacheCheck {
+	check := CacheCheck{CDN: cdn, URL: object}
+
+	u, err := url.Parse(object)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	resolver, probe, err := resolveCacheAddress(u.Hostname(), servers, timeouts)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.Resolver, check.Address, check.ContainerID = resolver, probe.Address, probe.ContainerID
+
+	client := cacheClient(u, check.Address, timeouts.Heartbeat)
+	client.Timeout = fetchTimeout
+	defer client.CloseIdleConnections()
+
+	for _, status := range []*string{&check.First, &check.Second} {
+		resp, err := client.Get(object)
+		if err != nil {
+			check.Error = err.Error()
+			return check
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			check.Error = "HTTP " + resp.Status
+			return check
+		}
+		*status = strings.ToUpper(resp.Header.Get(cacheStatusHeader))
+	}
+
+	check.FirstMiss = check.First == cacheMiss
+	check.Effective = check.Second == cacheHit
+	return check
+}
+
+func statusOrNone(status string) string {
+	if status == "" {
+		return "no " + cacheStatusHeader
+	}
+	return status
+}
+
+func resolveCacheAddress(hostname string, servers []string, timeouts Timeouts) (string, Probe, error) {
+	var resolved []string
+	for _, resolver := range servers {
+		ips, _, err := resolveIP(hostname, resolver, timeouts.DNS)
+		if err != nil {
+			continue
+		}
+
+		for _, ip := range ips {
+			if probe := probeHeartbeat(hostname, ip, timeouts.Heartbeat); probe.ContainerID != "" {
+				return resolver, probe, nil
+			}
+			if !slices.Contains(resolved, ip) {
+				resolved = append(resolved, ip)
+			}
+		}
+	}
+
+	if len(resolved) > 0 {
+		return "", Probe{}, fmt.Errorf("no lancache answered the heartbeat for %s on %s", hostname, strings.Join(resolved, ", "))
+	}
+	return "", Probe{}, fmt.Errorf("unable to resolve %s with %s", hostname, strings.Join(servers, ", "))
+}
+
+func cacheClient(u *url.URL, address string, timeout time.Duration) *http.Client {
+	port := u.Port()
+	if port == "" {
+		port = portHTTP
+	}
+
+	dialer := net.Dialer{
+		Timeout: timeout,
+	}
+	transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, net.JoinHostPort(address, port))
+	}}
+
+	return &http.Client{Transport: transport}
+}
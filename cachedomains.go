@@ -0,0 +1,118 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+//go:embed cache-domains
+var embeddedCacheDomains embed.FS
+
+func newCacheDomainsSource(path string) *CacheDomainsSource {
+	return &CacheDomainsSource{Path: path}
+}
+
+func cacheDomainsLines(source *CacheDomainsSource, file string, logger io.Writer) ([]string, error) {
+	switch source.Path {
+	case "":
+		if source.offline {
+			return fileToLines(embeddedCacheDomains, path.Join("cache-domains", file))
+		}
+		lines, err := urlToLines(cacheRepo+file, logger)
+		if err == nil {
+			return lines, nil
+		}
+
+		// Only an unreachable repository switches the rest of the run to the
+		// snapshot, a missing file falls back for that file alone.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			source.offline = true
+			_, _ = fmt.Fprintf(logger, "Unable to fetch %s (%v), using embedded cache-domains snapshot for the rest of the run\n", file, err)
+		} else {
+			_, _ = fmt.Fprintf(logger, "Unable to fetch %s (%v), using embedded cache-domains snapshot\n", file, err)
+		}
+		return fileToLines(embeddedCacheDomains, path.Join("cache-domains", file))
+	case cacheEmbedded:
+		return fileToLines(embeddedCacheDomains, path.Join("cache-domains", file))
+	default:
+		return fileToLines(os.DirFS(source.Path), file)
+	}
+}
+
+func loadCDNs(source *CacheDomainsSource, logger io.Writer) []CDN {
+	lines, err := cacheDomainsLines(source, cacheManifest, logger)
+	if err != nil {
+		_, _ = fmt.Fprintf(logger, "Unable to load %s (%v), using built-in CDN list\n", cacheManifest, err)
+		return defaultCDNs
+	}
+
+	var manifest CacheDomains
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &manifest); err != nil {
+		_, _ = fmt.Fprintf(logger, "Unable to parse %s (%v), using built-in CDN list\n", cacheManifest, err)
+		return defaultCDNs
+	}
+
+	var cdns []CDN
+	for _, domain := range manifest.CacheDomains {
+		if domain.Name == "" || len(domain.DomainFiles) == 0 {
+			continue
+		}
+
+		cdn := CDN{
+			Key:         domain.Name,
+			Name:        domain.Name,
+			Description: domain.Description,
+			Files:       domain.DomainFiles,
+		}
+		if known, ok := findCDN(defaultCDNs, domain.Name); ok {
+			cdn.Name = known.Name
+		}
+		cdns = append(cdns, cdn)
+	}
+
+	if len(cdns) == 0 {
+		_, _ = fmt.Fprintf(logger, "No CDNs found in %s, using built-in CDN list\n", cacheManifest)
+		return defaultCDNs
+	}
+
+	slices.SortFunc(cdns, func(a, b CDN) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return cdns
+}
+
+func findCDN(cdns []CDN, name string) (CDN, bool) {
+	for _, cdn := range cdns {
+		if strings.EqualFold(cdn.Name, name) || strings.EqualFold(cdn.Key, name) {
+			return cdn, true
+		}
+	}
+	return CDN{}, false
+}
+
+func cacheDomainsDir(source string) (string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		if filepath.Base(source) != cacheManifest {
+			return "", fmt.Errorf("%s is neither a directory nor %s", source, cacheManifest)
+		}
+		return filepath.Dir(source), nil
+	}
+
+	return source, nil
+}
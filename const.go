@@ -1,136 +1,310 @@
 package main
 
+import (
+	"errors"
+	"net/netip"
+	"time"
+)
+
 const (
-	diagSimple = "Diagnostics - Simple"
-	diagFull   = "Diagnostics - Full"
-	diagCustom = "Diagnostics - Custom"
+	diagSimple    = "Diagnostics - Simple"
+	diagFull      = "Diagnostics - Full"
+	diagCustom    = "Diagnostics - Custom"
+	diagMonitor   = "Monitor"
+	diagExporter  = "Exporter"
+	diagHostnames = "Diagnostics - Hostnames"
+	diagBenchmark = "Benchmark"
+	diagMatrix    = "Resolver Matrix"
+
+	hostnamesReport        = "Hostnames"
+	steamDiagnosticsReport = "Steam Diagnostics Address"
+	profilePrefix          = "Profile - "
+	configFile             = "lancache-diagnostics/config.json"
 
 	running    = "running"
 	loopback   = "loopback"
 	resolvConf = "/etc/resolv.conf"
 
+	resolvedConf          = "/run/systemd/resolve/resolv.conf"
+	resolvedLinks         = "/run/systemd/resolve/netif"
+	networkdLinks         = "/run/systemd/netif/links"
+	networkManagerDevices = "/run/NetworkManager/devices"
+
+	sourceResolved       = "systemd-resolved"
+	sourceNetworkd       = "systemd-networkd"
+	sourceNetworkManager = "NetworkManager"
+	sourceWindows        = "adapter"
+	sourceScutil         = "scutil"
+	sourceOverride       = "--nameserver"
+	sourceLinks          = "interfaces"
+
+	resolverDir = "/etc/resolver"
+
+	dhclientServersOption = "option domain-name-servers"
+
 	cacheRepo       = "https://raw.githubusercontent.com/uklans/cache-domains/master/"
+	cacheEmbedded   = "embedded"
+	cacheManifest   = "cache_domains.json"
 	heartbeatSuffix = "/lancache-heartbeat"
 	httpPrefix      = "http://"
 	lancacheHeader  = "X-Lancache-Processed-By"
-	testHostname    = "lancache.steamcontent.com"
+
+	cacheStatusHeader = "X-Upstream-Cache-Status"
+	cacheHit          = "HIT"
+	cacheMiss         = "MISS"
+	testHostname      = "lancache.steamcontent.com"
+	whoamiHostname    = "whoami.akamai.net"
+	randomPrefix      = "lancache-diagnostics-"
 
 	testPrefix     = "lancachetest."
 	wildcardPrefix = "*."
 
-	portHTTP = ":80"
-	portDNS  = ":53"
+	portHTTP  = "80"
+	portHTTPS = "443"
+	portDNS   = "53"
+	portDoT   = "853"
+
+	transportUDP   = "udp"
+	transportTCP   = "tcp"
+	transportTLS   = "tls"
+	transportHTTPS = "https"
+
+	dohPath        = "/dns-query"
+	dohContentType = "application/dns-message"
+
+	policySystem     = "system"
+	policyConfigured = "configured"
+	policyCustom     = "custom"
+	policyAll        = "all"
+
+	formatText = "text"
+	formatJSON = "json"
+
+	textReport          = "diagnostics.txt"
+	jsonReport          = "diagnostics.json"
+	reportSchemaVersion = 3
+
+	defaultWorkers  = 16
+	defaultInterval = 10 * time.Second
+
+	defaultHeartbeatTimeout = 1 * time.Second
+	defaultDNSTimeout       = 1 * time.Second
+	defaultIterations       = 1
+	defaultSimpleIterations = 6
+	defaultBenchCount       = 5
+	defaultListen           = ":9469"
+
+	metricsPath        = "/metrics"
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+	fetchTimeout       = 10 * time.Second
+)
+
+const (
+	reasonDNSNXDomain    FailureReason = "dns_nxdomain"
+	reasonDNSNoData      FailureReason = "dns_nodata"
+	reasonDNSTimeout     FailureReason = "dns_timeout"
+	reasonDNSError       FailureReason = "dns_error"
+	reasonTCPRefused     FailureReason = "tcp_refused"
+	reasonTCPError       FailureReason = "tcp_error"
+	reasonHTTPTimeout    FailureReason = "http_timeout"
+	reasonHTTPError      FailureReason = "http_error"
+	reasonMissingHeader  FailureReason = "missing_header"
+	reasonPublicAddress  FailureReason = "public_address"
+	reasonIPv6Bypass     FailureReason = "ipv6_bypass"
+	reasonPartialCluster FailureReason = "partial_cluster"
+	reasonTLSRefused     FailureReason = "tls_refused"
+	reasonTLSTimeout     FailureReason = "tls_timeout"
+	reasonTLSCertificate FailureReason = "tls_certificate"
+	reasonTLSError       FailureReason = "tls_error"
+
+	classRFC1918     AddressClass = "rfc1918"
+	classULA         AddressClass = "ula"
+	classCGNAT       AddressClass = "cgnat"
+	classLoopback    AddressClass = "loopback"
+	classLinkLocal   AddressClass = "link_local"
+	classUnspecified AddressClass = "unspecified"
+	classPublic      AddressClass = "public"
+	classUnknown     AddressClass = "unknown"
 )
 
 var (
-	CDNs = []CDN{ArenaNet, Blizzard, BattleStateGames, CallOfDuty, CityOfHeroes, DaybreakGames, EpicGames, Frontier, Neverwinter,
+	defaultCDNs = []CDN{ArenaNet, Blizzard, BattleStateGames, CallOfDuty, CityOfHeroes, DaybreakGames, EpicGames, Frontier, Neverwinter,
 		NexusMods, Nintendo, Origin, PathOfExile, RenegadeX, RiotGames, RockstarGames, Sony, SquareEnix, Steam, Test,
 		TheElderScrollsOnline, UPlay, Warframe, Wargaming, WindowsUpdates, XboxLive}
 
 	ArenaNet = CDN{
-		Name: "ArenaNet",
-		File: "arenanet.txt",
+		Key:   "arenanet",
+		Name:  "ArenaNet",
+		Files: []string{"arenanet.txt"},
 	}
 	Blizzard = CDN{
-		Name: "Blizzard",
-		File: "blizzard.txt",
+		Key:   "blizzard",
+		Name:  "Blizzard",
+		Files: []string{"blizzard.txt"},
 	}
 	BattleStateGames = CDN{
-		Name: "Battle State Games",
-		File: "bsg.txt",
+		Key:   "bsg",
+		Name:  "Battle State Games",
+		Files: []string{"bsg.txt"},
 	}
 	CallOfDuty = CDN{
-		Name: "Call of Duty",
-		File: "cod.txt",
+		Key:   "cod",
+		Name:  "Call of Duty",
+		Files: []string{"cod.txt"},
 	}
 	CityOfHeroes = CDN{
-		Name: "City of Heroes",
-		File: "cityofheroes.txt",
+		Key:   "cityofheroes",
+		Name:  "City of Heroes",
+		Files: []string{"cityofheroes.txt"},
 	}
 	DaybreakGames = CDN{
-		Name: "Daybreak Games",
-		File: "daybreak.txt",
+		Key:   "daybreak",
+		Name:  "Daybreak Games",
+		Files: []string{"daybreak.txt"},
 	}
 	EpicGames = CDN{
-		Name: "Epic Games",
-		File: "epicgames.txt",
+		Key:   "epicgames",
+		Name:  "Epic Games",
+		Files: []string{"epicgames.txt"},
 	}
 	Frontier = CDN{
-		Name: "Frontier",
-		File: "frontier.txt",
+		Key:   "frontier",
+		Name:  "Frontier",
+		Files: []string{"frontier.txt"},
 	}
 	Neverwinter = CDN{
-		Name: "Neverwinter",
-		File: "neverwinter.txt",
+		Key:   "neverwinter",
+		Name:  "Neverwinter",
+		Files: []string{"neverwinter.txt"},
 	}
 	NexusMods = CDN{
-		Name: "Nexus Mods",
-		File: "nexusmods.txt",
+		Key:   "nexusmods",
+		Name:  "Nexus Mods",
+		Files: []string{"nexusmods.txt"},
 	}
 	Nintendo = CDN{
-		Name: "Nintendo",
-		File: "nintendo.txt",
+		Key:   "nintendo",
+		Name:  "Nintendo",
+		Files: []string{"nintendo.txt"},
 	}
 	Origin = CDN{
-		Name: "Origin",
-		File: "origin.txt",
+		Key:   "origin",
+		Name:  "Origin",
+		Files: []string{"origin.txt"},
 	}
 	PathOfExile = CDN{
-		Name: "Path of Exile",
-		File: "pathofexile.txt",
+		Key:   "pathofexile",
+		Name:  "Path of Exile",
+		Files: []string{"pathofexile.txt"},
 	}
 	RenegadeX = CDN{
-		Name: "RenegadeX",
-		File: "renegadex.txt",
+		Key:   "renegadex",
+		Name:  "RenegadeX",
+		Files: []string{"renegadex.txt"},
 	}
 	RiotGames = CDN{
-		Name: "Riot Games",
-		File: "riot.txt",
+		Key:   "riot",
+		Name:  "Riot Games",
+		Files: []string{"riot.txt"},
 	}
 	RockstarGames = CDN{
-		Name: "Rockstar Games",
-		File: "rockstar.txt",
+		Key:   "rockstar",
+		Name:  "Rockstar Games",
+		Files: []string{"rockstar.txt"},
 	}
 	Sony = CDN{
-		Name: "Sony",
-		File: "sony.txt",
+		Key:   "sony",
+		Name:  "Sony",
+		Files: []string{"sony.txt"},
 	}
 	SquareEnix = CDN{
-		Name: "SQUARE ENIX",
-		File: "square.txt",
+		Key:   "square",
+		Name:  "SQUARE ENIX",
+		Files: []string{"square.txt"},
 	}
 	Steam = CDN{
-		Name: "Steam",
-		File: "steam.txt",
+		Key:   "steam",
+		Name:  "Steam",
+		Files: []string{"steam.txt"},
 	}
 	Test = CDN{
-		Name: "Test",
-		File: "test.txt",
+		Key:   "test",
+		Name:  "Test",
+		Files: []string{"test.txt"},
 	}
 	TheElderScrollsOnline = CDN{
-		Name: "The Elder Scrolls Online",
-		File: "teso.txt",
+		Key:   "teso",
+		Name:  "The Elder Scrolls Online",
+		Files: []string{"teso.txt"},
 	}
 	UPlay = CDN{
-		Name: "UPlay",
-		File: "uplay.txt",
+		Key:   "uplay",
+		Name:  "UPlay",
+		Files: []string{"uplay.txt"},
 	}
 	Warframe = CDN{
-		Name: "Warframe",
-		File: "warframe.txt",
+		Key:   "warframe",
+		Name:  "Warframe",
+		Files: []string{"warframe.txt"},
 	}
 	Wargaming = CDN{
-		Name: "WARGAMING",
-		File: "wargaming.net.txt",
+		Key:   "wargaming",
+		Name:  "WARGAMING",
+		Files: []string{"wargaming.net.txt"},
 	}
 	WindowsUpdates = CDN{
-		Name: "Windows Updates",
-		File: "windowsupdates.txt",
+		Key:   "windowsupdates",
+		Name:  "Windows Updates",
+		Files: []string{"windowsupdates.txt"},
 	}
 	XboxLive = CDN{
-		Name: "Xbox Live",
-		File: "xboxlive.txt",
+		Key:   "xboxlive",
+		Name:  "Xbox Live",
+		Files: []string{"xboxlive.txt"},
 	}
 
 	systemResolver = []string{"system"}
+
+	cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+	resolvedStubs = []string{"127.0.0.53", "127.0.0.54"}
+
+	keyValueLeases = []string{
+		"/run/systemd/netif/leases/*",
+		"/var/lib/NetworkManager/internal-*.lease",
+	}
+	dhclientLeases = []string{
+		"/var/lib/dhcp/dhclient*.leases",
+		"/var/lib/dhclient/*.lease*",
+		"/var/lib/NetworkManager/dhclient-*.lease",
+	}
+
+	defaultCacheObjects = map[string]string{
+		Steam.Name:          "http://cdn.akamai.steamstatic.com/client/installer/SteamSetup.exe",
+		Origin.Name:         "http://origin-a.akamaihd.net/EA-Desktop-Client-Download/installer-releases/EAappInstaller.exe",
+		WindowsUpdates.Name: "http://download.windowsupdate.com/msdownload/update/v3/static/trustedr/en/authrootstl.cab",
+	}
+
+	errNoData = errors.New("no address records in answer")
+
+	interceptionResolvers = []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}
+
+	dohEndpoints = []string{
+		"https://cloudflare-dns.com/dns-query",
+		"https://dns.google/dns-query",
+		"https://dns.quad9.net/dns-query",
+		"https://doh.opendns.com/dns-query",
+		"https://dns.adguard-dns.com/dns-query",
+		"https://dns.nextdns.io/dns-query",
+	}
+
+	modes = map[string]string{
+		"simple":    diagSimple,
+		"full":      diagFull,
+		"custom":    diagCustom,
+		"monitor":   diagMonitor,
+		"exporter":  diagExporter,
+		"hostnames": diagHostnames,
+		"benchmark": diagBenchmark,
+	}
 )
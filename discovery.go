@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+func discoverResolvers(override []string) (*dns.ClientConfig, []LinkDNS, []DiscoverySource) {
+	config, links, source, err := dnsClientConfig()
+
+	platform := DiscoverySource{Source: source}
+	switch {
+	case err != nil:
+		platform.Error = err.Error()
+	case len(config.Servers) == 0:
+		platform.Error = "no DNS servers configured"
+	default:
+		platform.Servers = config.Servers
+	}
+	if config == nil {
+		config = &dns.ClientConfig{Port: portDNS, Ndots: 1, Timeout: 5, Attempts: 2}
+	}
+	config.Servers = nil
+
+	sources := []DiscoverySource{platform}
+	if len(override) > 0 {
+		sources = append(sources, DiscoverySource{Source: sourceOverride, Servers: override})
+	}
+	sources = append(sources, fallbackSources(source)...)
+
+	var linkServers []string
+	for _, link := range links {
+		linkServers = append(linkServers, link.Servers...)
+	}
+	if len(linkServers) > 0 {
+		sources = append(sources, DiscoverySource{Source: sourceLinks, Servers: linkServers})
+	}
+
+	for i := range sources {
+		if len(override) > 0 && sources[i].Source != sourceOverride {
+			continue
+		}
+		if sources[i].Error == "" && len(sources[i].Servers) > 0 {
+			sources[i].Used = true
+			config.Servers = sources[i].Servers
+			break
+		}
+	}
+
+	return config, links, sources
+}
+
+func fallbackSources(platform string) []DiscoverySource {
+	var sources []DiscoverySource
+
+	if platform != resolvedConf {
+		config, err := dns.ClientConfigFromFile(resolvedConf)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			sources = append(sources, DiscoverySource{Source: resolvedConf, Error: err.Error()})
+		default:
+			sources = append(sources, DiscoverySource{Source: resolvedConf, Servers: config.Servers})
+		}
+	}
+
+	for _, pattern := range keyValueLeases {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			source := DiscoverySource{Source: path}
+			if values, err := readKeyValues(path); err != nil {
+				source.Error = err.Error()
+			} else {
+				source.Servers = strings.Fields(values["DNS"])
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	for _, pattern := range dhclientLeases {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			source := DiscoverySource{Source: path}
+			if servers, err := dhclientLeaseServers(path); err != nil {
+				source.Error = err.Error()
+			} else {
+				source.Servers = servers
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+func dhclientLeaseServers(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, dhclientServersOption) {
+			continue
+		}
+
+		// later leases in the file are newer, so the last one wins
+		servers = nil
+		for _, server := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(line, dhclientServersOption), ";"), ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				servers = append(servers, server)
+			}
+		}
+	}
+
+	return servers, scanner.Err()
+}
@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !linux && !darwin
 
 package main
 
@@ -6,6 +6,9 @@ import (
 	"github.com/miekg/dns"
 )
 
-func dnsClientConfig() (*dns.ClientConfig, error) {
-	return dns.ClientConfigFromFile(resolvConf)
+const platformSource = resolvConf
+
+func dnsClientConfig() (*dns.ClientConfig, []LinkDNS, string, error) {
+	config, err := dns.ClientConfigFromFile(resolvConf)
+	return config, nil, platformSource, err
 }
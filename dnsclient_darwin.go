@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+
+	"github.com/miekg/dns"
+)
+
+const platformSource = sourceScutil
+
+func dnsClientConfig() (*dns.ClientConfig, []LinkDNS, string, error) {
+	if out, err := exec.Command("scutil", "--dns").Output(); err == nil {
+		if resolvers, err := parseScutilDNS(bytes.NewReader(out)); err == nil {
+			config, links := scutilClientConfig(resolvers)
+			if len(config.Servers) > 0 {
+				return config, links, platformSource, nil
+			}
+		}
+	}
+
+	config, err := dns.ClientConfigFromFile(resolvConf)
+	if err != nil {
+		return nil, nil, resolvConf, err
+	}
+
+	return config, resolverDirLinks(resolverDir), resolvConf, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const platformSource = resolvConf
+
+func dnsClientConfig() (*dns.ClientConfig, []LinkDNS, string, error) {
+	config, err := dns.ClientConfigFromFile(resolvConf)
+	if err != nil {
+		return nil, nil, platformSource, err
+	}
+
+	source := platformSource
+	if isResolvedStub(config.Servers) {
+		if upstream, err := dns.ClientConfigFromFile(resolvedConf); err == nil && len(upstream.Servers) > 0 {
+			config.Servers = upstream.Servers
+			source = resolvedConf
+		}
+	}
+
+	return config, linkResolvers(), source, nil
+}
+
+func isResolvedStub(servers []string) bool {
+	if len(servers) == 0 {
+		return false
+	}
+
+	for _, server := range servers {
+		if !slices.Contains(resolvedStubs, server) {
+			return false
+		}
+	}
+	return true
+}
+
+func linkResolvers() []LinkDNS {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var links []LinkDNS
+	for _, i := range interfaces {
+		if i.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		index := strconv.Itoa(i.Index)
+		if link, ok := resolvedLink(i.Name, filepath.Join(resolvedLinks, index)); ok {
+			links = append(links, link)
+			continue
+		}
+		if link, ok := networkdLink(i.Name, filepath.Join(networkdLinks, index)); ok {
+			links = append(links, link)
+			continue
+		}
+		if link, ok := networkManagerLink(i.Name, filepath.Join(networkManagerDevices, index)); ok {
+			links = append(links, link)
+		}
+	}
+
+	return links
+}
+
+func resolvedLink(name, path string) (LinkDNS, bool) {
+	values, err := readKeyValues(path)
+	if err != nil {
+		return LinkDNS{}, false
+	}
+
+	link := LinkDNS{
+		Interface: name,
+		Domains:   strings.Fields(values["DOMAINS"]),
+		Source:    sourceResolved,
+	}
+	for _, server := range strings.Fields(values["SERVERS"]) {
+		if address := resolvedServer(server); address != "" {
+			link.Servers = append(link.Servers, address)
+		}
+	}
+
+	return link, len(link.Servers) > 0
+}
+
+// resolvedServer strips the port, interface index and server name from an
+// entry such as [fe80::1]:53%3#dns.example, keeping the zone of IPv6 addresses.
+func resolvedServer(server string) string {
+	server, _, _ = strings.Cut(server, "#")
+
+	var zone string
+	if i := strings.LastIndex(server, "%"); i >= 0 {
+		server, zone = server[:i], server[i+1:]
+	}
+	if net.ParseIP(server) == nil {
+		host, _, err := net.SplitHostPort(server)
+		if err != nil || net.ParseIP(host) == nil {
+			return ""
+		}
+		server = host
+	}
+
+	if zone != "" && strings.Contains(server, ":") {
+		return server + "%" + zone
+	}
+	return server
+}
+
+func networkdLink(name, path string) (LinkDNS, bool) {
+	values, err := readKeyValues(path)
+	if err != nil {
+		return LinkDNS{}, false
+	}
+
+	link := LinkDNS{
+		Interface: name,
+		Servers:   strings.Fields(values["DNS"]),
+		Domains:   strings.Fields(values["DOMAINS"]),
+		Source:    sourceNetworkd,
+	}
+
+	return link, len(link.Servers) > 0
+}
+
+func networkManagerLink(name, path string) (LinkDNS, bool) {
+	values, err := readKeyValues(path)
+	if err != nil {
+		return LinkDNS{}, false
+	}
+
+	link := LinkDNS{
+		Interface: name,
+		Source:    sourceNetworkManager,
+	}
+	for _, key := range []string{"domain_name_servers", "dhcp6_name_servers"} {
+		link.Servers = append(link.Servers, strings.Fields(values[key])...)
+	}
+	link.Domains = strings.Fields(values["domain_search"])
+
+	return link, len(link.Servers) > 0
+}
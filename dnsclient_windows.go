@@ -8,12 +8,14 @@ import (
 	"golang.org/x/sys/windows"
 )
 
-func dnsClientConfig() (*dns.ClientConfig, error) {
+const platformSource = sourceWindows
+
+func dnsClientConfig() (*dns.ClientConfig, []LinkDNS, string, error) {
 	l := uint32(20000)
 	b := make([]byte, l)
 
 	if err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, windows.GAA_FLAG_INCLUDE_PREFIX, 0, (*windows.IpAdapterAddresses)(unsafe.Pointer(&b[0])), &l); err != nil {
-		return nil, err
+		return nil, nil, platformSource, err
 	}
 
 	var addresses []*windows.IpAdapterAddresses
@@ -22,6 +24,7 @@ func dnsClientConfig() (*dns.ClientConfig, error) {
 	}
 
 	resolvers := map[string]bool{}
+	var links []LinkDNS
 
 	for _, addr := range addresses {
 		for next := addr.FirstUnicastAddress; next != nil; next = next.Next {
@@ -30,6 +33,10 @@ func dnsClientConfig() (*dns.ClientConfig, error) {
 			}
 
 			if next.Address.IP() != nil {
+				link := LinkDNS{
+					Interface: windows.UTF16PtrToString(addr.FriendlyName),
+					Source:    sourceWindows,
+				}
 				for dnsServer := addr.FirstDnsServerAddress; dnsServer != nil; dnsServer = dnsServer.Next {
 					ip := dnsServer.Address.IP()
 					if ip.IsMulticast() || ip.IsLinkLocalMulticast() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
@@ -39,6 +46,10 @@ func dnsClientConfig() (*dns.ClientConfig, error) {
 						continue
 					}
 					resolvers[ip.String()] = true
+					link.Servers = append(link.Servers, ip.String())
+				}
+				if len(link.Servers) > 0 {
+					links = append(links, link)
 				}
 				break
 			}
@@ -57,5 +68,5 @@ func dnsClientConfig() (*dns.ClientConfig, error) {
 		Ndots:    1,
 		Timeout:  5,
 		Attempts: 1,
-	}, nil
+	}, links, platformSource, nil
 }
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func lookupSystem(hostname string, qtype uint16, timeout time.Duration) ([]string, error) {
+	network := "ip4"
+	if qtype == dns.TypeAAAA {
+		network = "ip6"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupIP(ctx, network, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, address := range addresses {
+		ips = append(ips, address.String())
+	}
+	return ips, nil
+}
+
+func parseResolver(resolver string) (DNSEndpoint, error) {
+	scheme, address, found := strings.Cut(resolver, "://")
+	if !found {
+		scheme, address = transportUDP, resolver
+	}
+
+	endpoint := DNSEndpoint{Transport: scheme}
+
+	switch scheme {
+	case transportUDP, transportTCP, transportTLS:
+		port := portDNS
+		if scheme == transportTLS {
+			port = portDoT
+		}
+
+		host := strings.TrimSuffix(address, "/")
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
+		}
+		host = strings.Trim(host, "[]")
+		if host == "" {
+			return endpoint, fmt.Errorf("missing resolver address in %q", resolver)
+		}
+
+		endpoint.Host = host
+		endpoint.Server = net.JoinHostPort(host, port)
+	case transportHTTPS:
+		u, err := url.Parse(resolver)
+		if err != nil {
+			return endpoint, err
+		}
+		if u.Path == "" {
+			u.Path = dohPath
+		}
+
+		endpoint.Host = u.Hostname()
+		endpoint.Server = u.String()
+	default:
+		return endpoint, fmt.Errorf("unsupported resolver scheme %q", scheme)
+	}
+
+	return endpoint, nil
+}
+
+func exchange(msg *dns.Msg, endpoint DNSEndpoint, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	switch endpoint.Transport {
+	case transportHTTPS:
+		return exchangeHTTPS(msg, endpoint.Server, timeout)
+	case transportTLS:
+		client := &dns.Client{
+			Net:       "tcp-tls",
+			Timeout:   timeout,
+			TLSConfig: &tls.Config{ServerName: endpoint.Host},
+		}
+		return client.Exchange(msg, endpoint.Server)
+	case transportTCP:
+		client := &dns.Client{Net: "tcp", Timeout: timeout}
+		return client.Exchange(msg, endpoint.Server)
+	}
+
+	client := &dns.Client{Timeout: timeout}
+	resp, rtt, err := client.Exchange(msg, endpoint.Server)
+	if err == nil && resp.Truncated {
+		client.Net = "tcp"
+		return client.Exchange(msg, endpoint.Server)
+	}
+	return resp, rtt, err
+}
+
+func exchangeHTTPS(msg *dns.Msg, server string, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", dohContentType)
+	req.Header.Set("Accept", dohContentType)
+
+	// DoH needs a TLS handshake on top of the query
+	client := &http.Client{Timeout: 2 * timeout}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("unexpected status %s from %s", resp.Status, server)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, 0, err
+	}
+	rtt := time.Since(start)
+
+	reply := new(dns.Msg)
+	if err := reply.Unpack(body); err != nil {
+		return nil, 0, err
+	}
+
+	return reply, rtt, nil
+}
+
+func queryDNS(hostname, resolver string, qtype uint16, timeout time.Duration) ([]string, DNSAnswer, error) {
+	answer := DNSAnswer{
+		Type:   dns.TypeToString[qtype],
+		Server: resolver,
+	}
+
+	endpoint, err := parseResolver(resolver)
+	if err != nil {
+		return nil, answer, err
+	}
+	answer.Transport = endpoint.Transport
+	answer.Server = endpoint.Server
+	server := endpoint.Server
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(hostname), qtype)
+
+	resp, rtt, err := exchange(msg, endpoint, timeout)
+	if err != nil {
+		return nil, answer, fmt.Errorf("lookup %s on %s: %w", hostname, server, err)
+	}
+
+	answer.Rcode = dns.RcodeToString[resp.Rcode]
+	answer.Authoritative = resp.Authoritative
+	answer.RecursionAvailable = resp.RecursionAvailable
+	answer.QueryTime = milliseconds(rtt)
+
+	var ips []string
+	for _, rr := range resp.Answer {
+		switch v := rr.(type) {
+		case *dns.CNAME:
+			answer.CNAMEs = append(answer.CNAMEs, strings.TrimSuffix(v.Target, "."))
+		case *dns.A:
+			ips = append(ips, v.A.String())
+		case *dns.AAAA:
+			ips = append(ips, v.AAAA.String())
+		default:
+			continue
+		}
+
+		if answer.TTL == 0 || rr.Header().Ttl < answer.TTL {
+			answer.TTL = rr.Header().Ttl
+		}
+	}
+
+	switch {
+	case resp.Rcode == dns.RcodeNameError:
+		return nil, answer, &net.DNSError{Err: "no such host", Name: hostname, Server: server, IsNotFound: true}
+	case resp.Rcode != dns.RcodeSuccess:
+		return nil, answer, &net.DNSError{Err: "server returned " + answer.Rcode, Name: hostname, Server: server}
+	case len(ips) == 0:
+		return nil, answer, fmt.Errorf("lookup %s on %s: %w", hostname, server, errNoData)
+	}
+
+	return ips, answer, nil
+}
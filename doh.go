@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func checkDoH(workers int, timeout time.Duration, logger io.Writer) []DoHCheck {
+	_, _ = fmt.Fprintf(logger, "Checking well-known DNS over HTTPS endpoints...\n")
+
+	checks := make([]DoHCheck, len(dohEndpoints))
+	runPool(workers, len(dohEndpoints), func(i int) {
+		check := DoHCheck{Endpoint: dohEndpoints[i]}
+
+		ips, answer, err := queryDNS(testHostname, dohEndpoints[i], dns.TypeA, timeout)
+		check.Reachable = answer.Rcode != ""
+		check.Addresses = ips
+		if err != nil {
+			check.Error = err.Error()
+		}
+		checks[i] = check
+	})
+
+	for _, check := range checks {
+		switch {
+		case !check.Reachable:
+			_, _ = fmt.Fprintf(logger, "%s is not reachable\n", check.Endpoint)
+		case len(check.Addresses) > 0:
+			_, _ = fmt.Fprintf(logger, "Warning: %s is reachable and resolves %s to %s — clients using it bypass lancache-dns\n",
+				check.Endpoint, testHostname, strings.Join(check.Addresses, ", "))
+		default:
+			_, _ = fmt.Fprintf(logger, "Warning: %s is reachable — clients using it bypass lancache-dns\n", check.Endpoint)
+		}
+	}
+	_, _ = fmt.Fprintf(logger, "\n")
+
+	return checks
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func exporter(opts Options) error {
+	targets, err := optionTargets(opts)
+	if err != nil {
+		return err
+	}
+
+	e := newExporter(targets, opts.Workers, opts.Timeouts)
+	go e.run(opts.Interval)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(metricsPath, e.serveMetrics)
+
+	fmt.Printf("Serving metrics for %d target(s) on %s%s\n", len(e.Targets), opts.Listen, metricsPath)
+	return http.ListenAndServe(opts.Listen, mux)
+}
+
+func newExporter(targets []MonitorTarget, workers int, timeouts Timeouts) *Exporter {
+	e := &Exporter{
+		Targets:  targets,
+		Workers:  workers,
+		Timeouts: timeouts,
+		totals:   make([]int, len(targets)),
+		failures: make([]map[FailureReason]int, len(targets)),
+	}
+	for i := range e.failures {
+		e.failures[i] = map[FailureReason]int{}
+	}
+	return e
+}
+
+func (e *Exporter) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.check()
+		<-ticker.C
+	}
+}
+
+func (e *Exporter) check() {
+	start := time.Now()
+	lookups := checkTargets(e.Targets, e.Workers, e.Timeouts)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lookups = lookups
+	e.checked = time.Now()
+	e.duration = time.Since(start)
+	for i, lookup := range lookups {
+		e.totals[i]++
+		if !lookup.Passed {
+			e.failures[i][lookup.Reason]++
+		}
+	}
+}
+
+func (e *Exporter) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	e.mu.Lock()
+	body := e.metrics()
+	e.mu.Unlock()
+
+	w.Header().Set("Content-Type", metricsContentType)
+	_, _ = w.Write(body)
+}
+
+func (e *Exporter) metrics() []byte {
+	var b bytes.Buffer
+
+	metric := func(name, kind, help string) {
+		_, _ = fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	sample := func(name string, value float64, labels ...string) {
+		var pairs []string
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+		}
+		if len(pairs) > 0 {
+			_, _ = fmt.Fprintf(&b, "%s{%s} %g\n", name, strings.Join(pairs, ","), value)
+		} else {
+			_, _ = fmt.Fprintf(&b, "%s %g\n", name, value)
+		}
+	}
+	target := func(i int, labels ...string) []string {
+		return append([]string{"cdn", e.Targets[i].CDN, "hostname", e.Targets[i].Hostname, "resolver", e.Targets[i].Resolver}, labels...)
+	}
+
+	metric("lancache_checks_total", "counter", "Number of checks run per CDN and resolver.")
+	for i, total := range e.totals {
+		sample("lancache_checks_total", float64(total), target(i)...)
+	}
+
+	metric("lancache_check_failures_total", "counter", "Number of failed checks per CDN, resolver and failure reason.")
+	for i, failures := range e.failures {
+		for _, reason := range slices.Sorted(maps.Keys(failures)) {
+			sample("lancache_check_failures_total", float64(failures[reason]), target(i, "reason", string(reason))...)
+		}
+	}
+
+	if e.checked.IsZero() {
+		return b.Bytes()
+	}
+
+	metric("lancache_last_check_timestamp_seconds", "gauge", "Time the last round of checks finished.")
+	sample("lancache_last_check_timestamp_seconds", float64(e.checked.Unix()))
+	metric("lancache_last_check_duration_seconds", "gauge", "Time the last round of checks took.")
+	sample("lancache_last_check_duration_seconds", e.duration.Seconds())
+
+	metric("lancache_lookup_success", "gauge", "Whether the last lookup resolved to a healthy lancache, 1 for success.")
+	for i, lookup := range e.lookups {
+		value := 0.0
+		if lookup.Passed {
+			value = 1
+		}
+		sample("lancache_lookup_success", value, target(i)...)
+	}
+
+	metric("lancache_dns_query_seconds", "gauge", "Query time of the last DNS lookup, not available for the system resolver.")
+	for i, lookup := range e.lookups {
+		for _, answer := range lookup.DNS {
+			sample("lancache_dns_query_seconds", answer.QueryTime/1000, target(i, "type", answer.Type)...)
+		}
+	}
+
+	metric("lancache_heartbeat_latency_seconds", "gauge", "Latency of the last heartbeat request per resolved address.")
+	for i, lookup := range e.lookups {
+		for _, probe := range lookup.Probes {
+			if probe.Reachable {
+				sample("lancache_heartbeat_latency_seconds", probe.Latency/1000, target(i, "address", probe.Address)...)
+			}
+		}
+	}
+
+	metric("lancache_container_info", "gauge", "Container ID reported in the "+lancacheHeader+" header per resolved address.")
+	for i, lookup := range e.lookups {
+		for _, probe := range lookup.Probes {
+			if probe.ContainerID != "" {
+				sample("lancache_container_info", 1, target(i, "address", probe.Address, "container_id", probe.ContainerID)...)
+			}
+		}
+	}
+
+	return b.Bytes()
+}
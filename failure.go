@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+func classifyDNSError(err error) FailureReason {
+	if errors.Is(err, errNoData) {
+		return reasonDNSNoData
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return reasonDNSNXDomain
+		case dnsErr.IsTimeout:
+			return reasonDNSTimeout
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return reasonDNSTimeout
+	}
+
+	return reasonDNSError
+}
+
+func classifyHTTPError(err error) FailureReason {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return reasonTCPRefused
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return reasonHTTPTimeout
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return reasonTCPError
+	}
+
+	return reasonHTTPError
+}
+
+func classifyTLSError(err error) FailureReason {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return reasonTLSRefused
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return reasonTLSTimeout
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return reasonTLSCertificate
+	}
+
+	return reasonTLSError
+}
+
+func countReasons(lookups []Lookup) map[FailureReason]int {
+	counts := map[FailureReason]int{}
+	for _, lookup := range lookups {
+		if lookup.Reason != "" {
+			counts[lookup.Reason]++
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	return counts
+}
+
+func summariseReasons(counts map[FailureReason]int) string {
+	var parts []string
+	for _, reason := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, fmt.Sprintf("%s: %d", reason, counts[reason]))
+	}
+
+	return strings.Join(parts, ", ")
+}
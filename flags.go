@@ -0,0 +1,234 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func defaultOptions() Options {
+	return Options{
+		Formats:  []string{formatText},
+		Workers:  defaultWorkers,
+		Interval: defaultInterval,
+		Listen:   defaultListen,
+		Timeouts: Timeouts{
+			Heartbeat: defaultHeartbeatTimeout,
+			DNS:       defaultDNSTimeout,
+		},
+		Iterations:       defaultIterations,
+		SimpleIterations: defaultSimpleIterations,
+		BenchCount:       defaultBenchCount,
+	}
+}
+
+func parseFlags(args []string, output io.Writer) (Options, error) {
+	var (
+		opts         = defaultOptions()
+		formats      listFlag
+		cacheObjects listFlag
+		config       string
+		profile      string
+	)
+
+	fs := flag.NewFlagSet("lancache-diagnostics", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.Mode, "mode", "", "run non-interactively in the given mode: simple, full, custom, hostnames, benchmark, monitor or exporter")
+	fs.Var((*listFlag)(&opts.CDNs), "cdn", "comma separated list of CDNs to test in custom, monitor or exporter mode, e.g. Steam,Blizzard")
+	fs.Var((*listFlag)(&opts.Hostnames), "host", "comma separated list of hostnames to test, required with --mode hostnames and looked up in addition to the CDNs in other modes")
+	fs.Var((*listFlag)(&opts.Resolvers), "resolver", "comma separated list of resolvers to test as address or udp://, tcp://, tls:// or https:// url, use \"system\" for the system resolver")
+	fs.Var((*listFlag)(&opts.Nameservers), "nameserver", "comma separated list of DNS servers to use as the configured resolvers when they cannot be discovered or are wrong")
+	fs.Var(&cacheObjects, "cache-object", "comma separated list of CDN=URL objects to download twice to check they are served from cache, e.g. Steam=http://host/path")
+	fs.StringVar(&opts.BenchURL, "bench-url", "", "http:// url of the object to download in benchmark mode, defaults to the first --cache-object")
+	fs.IntVar(&opts.BenchCount, "bench-count", opts.BenchCount, "number of times the object is downloaded in benchmark mode")
+	fs.Var(&formats, "format", "comma separated list of report formats to write: text, json")
+	fs.DurationVar(&opts.Timeouts.Heartbeat, "heartbeat-timeout", opts.Timeouts.Heartbeat, "timeout for connecting to and requesting the heartbeat from each address")
+	fs.DurationVar(&opts.Timeouts.DNS, "dns-timeout", opts.Timeouts.DNS, "timeout for each DNS query, doubled for DNS over HTTPS")
+	fs.IntVar(&opts.SimpleIterations, "simple-iterations", opts.SimpleIterations, "number of times the Steam diagnostics address is looked up")
+	fs.IntVar(&opts.Iterations, "iterations", opts.Iterations, "number of times each CDN hostname is looked up in full and custom mode")
+	fs.DurationVar(&opts.Interval, "interval", opts.Interval, "time between checks in monitor and exporter mode")
+	fs.StringVar(&opts.Listen, "listen", opts.Listen, "address to serve /metrics on in exporter mode")
+	fs.IntVar(&opts.Workers, "workers", opts.Workers, "number of lookups to run concurrently")
+	fs.StringVar(&opts.ResolverPolicy, "resolver-policy", "", "resolvers to test: system, configured, custom or all, defaults to custom when --resolver is given and all otherwise")
+	fs.BoolVar(&opts.DoHCheck, "doh-check", false, "warn when well-known DNS over HTTPS endpoints are reachable, always enabled in full mode")
+	fs.BoolVar(&opts.CacheCheck, "cache-check", false, "download an object per CDN twice to check it is served from cache and fail when it is not, using built-in objects unless --cache-object is given, always checked as a warning in full mode")
+	fs.BoolVar(&opts.SNICheck, "sni-check", false, "check that HTTPS on port 443 of every cache address passes through to the real upstream certificate and fail when it does not, always checked as a warning in full mode")
+	fs.BoolVar(&opts.InterceptionCheck, "interception-check", false, "check whether DNS traffic to public resolvers is intercepted or NXDOMAIN answers are rewritten, always enabled in full mode")
+	fs.StringVar(&opts.CacheDomains, "cache-domains", "", "local cache-domains checkout, directory or cache_domains.json to load CDN files from, or \"embedded\" for the built-in snapshot")
+	fs.StringVar(&config, "config", "", "config file with saved profiles, defaults to lancache-diagnostics/config.json in the user config directory")
+	fs.StringVar(&profile, "profile", "", "run non-interactively with the named profile from the config file, other flags take precedence")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("error: unexpected arguments %s", strings.Join(fs.Args(), " "))
+	}
+	if len(formats) > 0 {
+		opts.Formats = formats
+	}
+	for _, object := range cacheObjects {
+		cdn, u, found := strings.Cut(object, "=")
+		if !found {
+			return opts, fmt.Errorf("error: invalid --cache-object %q, expected CDN=URL", object)
+		}
+		if opts.CacheObjects == nil {
+			opts.CacheObjects = map[string]string{}
+		}
+		opts.CacheObjects[strings.TrimSpace(cdn)] = strings.TrimSpace(u)
+	}
+
+	opts.setFlags = map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		opts.setFlags[f.Name] = true
+	})
+
+	profiles, err := loadProfiles(config)
+	switch {
+	case err != nil && (config != "" || profile != ""):
+		return opts, fmt.Errorf("error: invalid --config %w", err)
+	case err != nil:
+		_, _ = fmt.Fprintf(output, "Warning: ignoring invalid config file %v\n", err)
+	}
+	opts.Profiles = profiles
+
+	if profile != "" {
+		p, ok := findProfile(profiles, profile)
+		if !ok {
+			return opts, fmt.Errorf("error: unknown profile %q", profile)
+		}
+		return applyProfile(opts, p)
+	}
+
+	return normaliseOptions(opts)
+}
+
+func normaliseOptions(opts Options) (Options, error) {
+	opts.Hostnames = parseHostnames(strings.Join(opts.Hostnames, "\n"))
+
+	for _, resolver := range opts.Resolvers {
+		if resolver == systemResolver[0] {
+			continue
+		}
+		if _, err := parseResolver(resolver); err != nil {
+			return opts, fmt.Errorf("error: invalid --resolver %w", err)
+		}
+	}
+
+	for _, nameserver := range opts.Nameservers {
+		if _, err := parseResolver(nameserver); err != nil {
+			return opts, fmt.Errorf("error: invalid --nameserver %w", err)
+		}
+	}
+
+	switch opts.ResolverPolicy {
+	case "":
+		if len(opts.Resolvers) > 0 {
+			opts.ResolverPolicy = policyCustom
+		}
+	case policySystem, policyConfigured:
+		if len(opts.Resolvers) > 0 {
+			return opts, fmt.Errorf("error: --resolver requires --resolver-policy custom or all")
+		}
+	case policyCustom:
+		if len(opts.Resolvers) == 0 {
+			return opts, fmt.Errorf("error: --resolver-policy custom requires at least one --resolver")
+		}
+	case policyAll:
+	default:
+		return opts, fmt.Errorf("error: unknown resolver policy %q", opts.ResolverPolicy)
+	}
+
+	for cdn, object := range opts.CacheObjects {
+		u, err := url.Parse(object)
+		if err != nil {
+			return opts, fmt.Errorf("error: invalid cache object for %s %w", cdn, err)
+		}
+		if u.Scheme != "http" || u.Host == "" {
+			return opts, fmt.Errorf("error: invalid cache object for %s, expected an http:// url", cdn)
+		}
+	}
+
+	if opts.BenchURL != "" {
+		if err := validateBenchURL(opts.BenchURL); err != nil {
+			return opts, fmt.Errorf("error: invalid --bench-url %w", err)
+		}
+	}
+	if opts.BenchCount < 1 {
+		return opts, fmt.Errorf("error: --bench-count must be at least 1")
+	}
+
+	for _, format := range opts.Formats {
+		if format != formatText && format != formatJSON {
+			return opts, fmt.Errorf("error: unknown format %q", format)
+		}
+	}
+
+	if opts.Workers < 1 {
+		return opts, fmt.Errorf("error: --workers must be at least 1")
+	}
+	if opts.Timeouts.Heartbeat <= 0 || opts.Timeouts.DNS <= 0 {
+		return opts, fmt.Errorf("error: --heartbeat-timeout and --dns-timeout must be positive")
+	}
+	if opts.Iterations < 1 || opts.SimpleIterations < 1 {
+		return opts, fmt.Errorf("error: --iterations and --simple-iterations must be at least 1")
+	}
+	if opts.Interval <= 0 {
+		return opts, fmt.Errorf("error: --interval must be positive")
+	}
+
+	if opts.CacheDomains != "" && opts.CacheDomains != cacheEmbedded {
+		dir, err := cacheDomainsDir(opts.CacheDomains)
+		if err != nil {
+			return opts, fmt.Errorf("error: invalid --cache-domains %w", err)
+		}
+		opts.CacheDomains = dir
+	}
+
+	if opts.Mode == "" {
+		if len(opts.CDNs) > 0 {
+			return opts, fmt.Errorf("error: --cdn requires --mode custom, monitor or exporter")
+		}
+		if len(opts.Hostnames) > 0 {
+			return opts, fmt.Errorf("error: --host requires --mode")
+		}
+		return opts, nil
+	}
+
+	selected, ok := modes[strings.ToLower(opts.Mode)]
+	if !ok {
+		return opts, fmt.Errorf("error: unknown mode %q", opts.Mode)
+	}
+	opts.Mode = selected
+
+	if len(opts.CDNs) > 0 && selected != diagCustom && selected != diagMonitor && selected != diagExporter {
+		return opts, fmt.Errorf("error: --cdn can only be used with --mode custom, monitor or exporter")
+	}
+	if selected == diagCustom && len(opts.CDNs) == 0 && len(opts.Hostnames) == 0 {
+		return opts, fmt.Errorf("error: --mode custom requires at least one --cdn")
+	}
+	if selected == diagBenchmark && opts.BenchURL == "" && len(opts.CacheObjects) == 0 {
+		return opts, fmt.Errorf("error: --mode benchmark requires --bench-url or --cache-object")
+	}
+	if selected == diagHostnames && len(opts.Hostnames) == 0 {
+		return opts, fmt.Errorf("error: --mode hostnames requires at least one --host")
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"charm.land/huh/v2"
+)
+
+func hostnames(servers []string, opts Options, logger io.Writer) CDNReport {
+	_, _ = fmt.Fprintf(logger, "Looking up hostnames %s...\n", strings.Join(opts.Hostnames, ", "))
+	return newCDNReport(hostnamesReport, lookupHostnames("", opts.Hostnames, opts.Iterations, opts.Workers, opts.Timeouts, servers, logger, nil, false))
+}
+
+func promptHostnames(logger io.Writer) []string {
+	var input string
+
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewText().
+			Title("Enter Hostname(s):").
+			Description("One per line or comma separated, wildcards such as *.steamcontent.com are allowed").
+			Lines(8).
+			Value(&input).
+			Validate(func(s string) error {
+				if len(parseHostnames(s)) == 0 {
+					return errors.New("at least one hostname is required")
+				}
+				return nil
+			}),
+	)).Run()
+	if err != nil {
+		_, _ = fmt.Fprint(logger, fmt.Errorf("error: prompt failed %w", err))
+		return nil
+	}
+
+	return parseHostnames(input)
+}
+
+func parseHostnames(input string) []string {
+	var hostnames []string
+	for _, line := range strings.Split(input, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		for _, hostname := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		}) {
+			hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+			if strings.HasPrefix(hostname, wildcardPrefix) {
+				hostname = strings.Replace(hostname, wildcardPrefix, testPrefix, 1)
+			}
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
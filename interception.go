@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func checkInterception(servers []string, workers int, timeout time.Duration, logger io.Writer) InterceptionReport {
+	_, _ = fmt.Fprintf(logger, "Checking for DNS interception...\n")
+
+	var resolvers []string
+	for _, server := range append(append([]string{}, servers...), interceptionResolvers...) {
+		if server != systemResolver[0] && !slices.Contains(resolvers, server) {
+			resolvers = append(resolvers, server)
+		}
+	}
+
+	random := randomPrefix + strings.ToLower(rand.Text()) + ".com"
+	report := InterceptionReport{
+		RandomHostname: random,
+		Resolvers:      make([]InterceptionResult, len(resolvers)),
+	}
+
+	runPool(workers, len(resolvers), func(i int) {
+		result := InterceptionResult{Resolver: resolvers[i]}
+
+		ips, answer, err := queryDNS(random, resolvers[i], dns.TypeA, timeout)
+		result.RandomRcode = answer.Rcode
+		result.RandomAddresses = ips
+		if answer.Rcode == "" && err != nil {
+			result.Error = err.Error()
+			report.Resolvers[i] = result
+			return
+		}
+
+		ips, answer, _ = queryDNS(whoamiHostname, resolvers[i], dns.TypeA, timeout)
+		result.Whoami = ips
+		result.WhoamiRcode = answer.Rcode
+		result.TestAddresses, _, _ = queryDNS(testHostname, resolvers[i], dns.TypeA, timeout)
+		report.Resolvers[i] = result
+	})
+
+	egress := map[string][]string{}
+	for _, result := range report.Resolvers {
+		if result.Error != "" {
+			_, _ = fmt.Fprintf(logger, "Unable to query %s: %s\n", result.Resolver, result.Error)
+			continue
+		}
+
+		if len(result.RandomAddresses) > 0 {
+			report.Findings = append(report.Findings, fmt.Sprintf("%s answers the non-existent name %s with %s, NXDOMAIN responses are being rewritten",
+				result.Resolver, random, strings.Join(result.RandomAddresses, ", ")))
+		}
+
+		if !slices.Contains(interceptionResolvers, result.Resolver) {
+			continue
+		}
+		if result.WhoamiRcode == dns.RcodeToString[dns.RcodeNameError] {
+			report.Findings = append(report.Findings, fmt.Sprintf("public resolver %s claims %s does not exist, port 53 traffic is likely redirected",
+				result.Resolver, whoamiHostname))
+		}
+		for _, ip := range result.TestAddresses {
+			if class := classifyAddress(ip); class != classPublic && class != classUnknown {
+				report.Findings = append(report.Findings, fmt.Sprintf("public resolver %s returned the %s address %s for %s, port 53 traffic is likely redirected",
+					result.Resolver, class, ip, testHostname))
+				break
+			}
+		}
+		for _, ip := range result.Whoami {
+			egress[ip] = append(egress[ip], result.Resolver)
+		}
+	}
+
+	for _, ip := range slices.Sorted(maps.Keys(egress)) {
+		if len(egress[ip]) > 1 {
+			report.Findings = append(report.Findings, fmt.Sprintf("public resolvers %s are all answered by %s, port 53 traffic is likely redirected",
+				strings.Join(egress[ip], ", "), ip))
+		}
+	}
+
+	report.Detected = len(report.Findings) > 0
+	for _, finding := range report.Findings {
+		_, _ = fmt.Fprintf(logger, "Warning: %s\n", finding)
+	}
+	if !report.Detected {
+		_, _ = fmt.Fprintf(logger, "No DNS interception detected on %d resolver(s)\n", len(resolvers))
+	}
+	_, _ = fmt.Fprintf(logger, "\n")
+
+	return report
+}
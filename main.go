@@ -2,20 +2,50 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/miekg/dns"
 )
 
 func main() {
+	opts, err := parseFlags(os.Args[1:], os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	if opts.Mode != "" {
+		if _, ok := run(opts); !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var last Report
 	for {
-		m := newModel("Select Mode:", []string{diagSimple, diagFull, diagCustom, "Exit"}, false)
+		items := []string{diagSimple, diagFull, diagCustom, diagHostnames, diagBenchmark, diagMonitor}
+		for _, profile := range opts.Profiles {
+			items = append(items, profilePrefix+profile.Name)
+		}
+		if last.Matrix != nil {
+			items = append(items, diagMatrix)
+		}
+		items = append(items, "Exit")
+
+		m := newModel("Select Mode:", items, false)
 		p := tea.NewProgram(&m)
 		fm, err := p.Run()
 		if err != nil {
@@ -23,95 +53,258 @@ func main() {
 			return
 		}
 
-		switch fm.(*Model).Selected {
-		case diagSimple, diagFull, diagCustom:
-			diagnostics(fm.(*Model).Selected)
+		selected := fm.(*Model).Selected
+		switch {
+		case selected == diagSimple, selected == diagFull, selected == diagCustom, selected == diagHostnames, selected == diagBenchmark, selected == diagMonitor:
+			selectedOpts := opts
+			selectedOpts.Mode = selected
+			last, _ = run(selectedOpts)
+		case selected == diagMatrix:
+			if err := showMatrix(last.Matrix); err != nil {
+				fmt.Println(fmt.Errorf("error: prompt failed %w", err))
+			}
+		case strings.HasPrefix(selected, profilePrefix):
+			profile, _ := findProfile(opts.Profiles, strings.TrimPrefix(selected, profilePrefix))
+			profileOpts, err := applyProfile(opts, profile)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			last, _ = run(profileOpts)
 		default:
 			return
 		}
 	}
 }
 
-func diagnostics(result string) {
-	f, err := os.Create("diagnostics.txt")
-	logger := io.MultiWriter(os.Stdout, f)
-	if err != nil {
-		_, _ = fmt.Fprint(logger, fmt.Errorf("error: %w", err))
+func run(opts Options) (Report, bool) {
+	switch opts.Mode {
+	case diagMonitor:
+		if err := monitor(opts); err != nil {
+			fmt.Println(fmt.Errorf("error: monitor failed %w", err))
+			return Report{}, false
+		}
+		return Report{}, true
+	case diagBenchmark:
+		if err := benchmark(opts); err != nil {
+			fmt.Println(fmt.Errorf("error: benchmark failed %w", err))
+			return Report{}, false
+		}
+		return Report{}, true
+	case diagExporter:
+		if err := exporter(opts); err != nil {
+			fmt.Println(fmt.Errorf("error: exporter failed %w", err))
+			return Report{}, false
+		}
+		return Report{}, true
 	}
 
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			_, _ = fmt.Fprint(logger, fmt.Errorf("error: %w", err))
+	report := diagnostics(opts)
+	return report, report.Passed
+}
+
+func diagnostics(opts Options) Report {
+	var (
+		logger  io.Writer = os.Stdout
+		logfile *os.File
+	)
+
+	if slices.Contains(opts.Formats, formatText) {
+		logger, logfile = createTextReport()
+		if logfile != nil {
+			defer closeTextReport(logfile, logger)
 		}
-	}(f)
+	}
 
-	getInterfaceAddresses(logger)
+	report := Report{
+		SchemaVersion: reportSchemaVersion,
+		Mode:          opts.Mode,
+		Time:          time.Now().Format(time.RFC3339),
 
-	d, err := dnsClientConfig()
-	if err != nil {
-		_, _ = fmt.Fprint(logger, fmt.Errorf("error: %w", err))
+		HeartbeatTimeout: milliseconds(opts.Timeouts.Heartbeat),
+		DNSTimeout:       milliseconds(opts.Timeouts.DNS),
+		SimpleIterations: opts.SimpleIterations,
+		Iterations:       opts.Iterations,
 	}
 
-	_, _ = fmt.Fprintf(logger, "DNS Server(s): %s\n\n", strings.Join(d.Servers, ", "))
+	_, _ = fmt.Fprintf(logger, "Heartbeat Timeout: %s, DNS Timeout: %s\n", opts.Timeouts.Heartbeat, opts.Timeouts.DNS)
+	_, _ = fmt.Fprintf(logger, "Iterations: %d (Steam diagnostics address), %d (CDN hostnames)\n\n", opts.SimpleIterations, opts.Iterations)
+
+	report.Interfaces = getInterfaceAddresses(logger)
 
-	d.Servers = append([]string{"system"}, d.Servers...)
-	if len(d.Servers) <= 2 {
-		d.Servers = []string{"system"}
+	d, links, discovery := discoverResolvers(opts.Nameservers)
+	for _, source := range discovery {
+		switch {
+		case source.Error != "":
+			_, _ = fmt.Fprintf(logger, "Unable to read DNS server(s) from %s: %s\n", source.Source, source.Error)
+		case source.Used:
+			_, _ = fmt.Fprintf(logger, "Using DNS server(s) from %s\n", source.Source)
+		}
+	}
+	if len(d.Servers) == 0 {
+		_, _ = fmt.Fprintf(logger, "Unable to discover any DNS server(s), use --nameserver to set them\n")
 	}
 
-	switch result {
+	_, _ = fmt.Fprintf(logger, "DNS Server(s): %s\n", strings.Join(d.Servers, ", "))
+	for _, link := range links {
+		_, _ = fmt.Fprintf(logger, "DNS Server(s) for %s: %s (%s)\n", link.Name(), strings.Join(link.Servers, ", "), link.Source)
+	}
+	if len(d.Search) > 0 {
+		_, _ = fmt.Fprintf(logger, "Search Domain(s): %s\n", strings.Join(d.Search, ", "))
+	}
+	_, _ = fmt.Fprintf(logger, "Ndots: %d\n", d.Ndots)
+	report.Resolvers = d.Servers
+	report.Links = links
+	report.Discovery = discovery
+	report.Search = d.Search
+	report.Ndots = d.Ndots
+
+	policy, simplePolicy := opts.ResolverPolicy, opts.ResolverPolicy
+	if policy == "" {
+		policy, simplePolicy = policyAll, policySystem
+	}
+	if opts.Mode == diagSimple {
+		policy = simplePolicy
+	}
+	servers := selectResolvers(policy, d.Servers, opts.Resolvers)
+	simpleServers := selectResolvers(simplePolicy, d.Servers, opts.Resolvers)
+
+	_, _ = fmt.Fprintf(logger, "Testing Resolver(s) (%s): %s\n", policy, strings.Join(servers, ", "))
+	report.ResolverPolicy = policy
+	report.TestedResolvers = servers
+	if opts.Mode == diagFull {
+		_, _ = fmt.Fprintf(logger, "Testing Steam Diagnostics Address with Resolver(s) (%s): %s\n", simplePolicy, strings.Join(simpleServers, ", "))
+		report.SteamResolvers = simpleServers
+	}
+	_, _ = fmt.Fprintf(logger, "\n")
+
+	var cdns []CDN
+	source := newCacheDomainsSource(opts.CacheDomains)
+	if opts.Mode != diagSimple && opts.Mode != diagHostnames {
+		cdns = loadCDNs(source, logger)
+	}
+
+	switch opts.Mode {
 	case diagSimple:
-		simple(systemResolver, logger)
+		report.CDNs = append(report.CDNs, simple(simpleServers, opts, logger))
 	case diagFull:
-		simple(systemResolver, logger)
-		full(d.Servers, logger, f)
+		report.CDNs = append(report.CDNs, simple(simpleServers, opts, logger))
+		report.CDNs = append(report.CDNs, full(cdns, source, servers, opts, logger, logfile)...)
 	case diagCustom:
-		custom(d.Servers, logger)
+		report.CDNs = append(report.CDNs, custom(cdns, source, servers, opts, logger)...)
+	case diagHostnames:
+		if len(opts.Hostnames) == 0 {
+			opts.Hostnames = promptHostnames(logger)
+		}
+	}
+
+	if len(opts.Hostnames) > 0 {
+		report.CDNs = append(report.CDNs, hostnames(servers, opts, logger))
+	}
+
+	if len(servers) > 1 && len(report.CDNs) > 0 {
+		report.Matrix = buildMatrix(report.CDNs, servers)
+		logMatrix(report.Matrix, logger, logfile)
+	}
+
+	if opts.SNICheck || opts.Mode == diagFull {
+		report.SNI = checkSNI(report.CDNs, opts.Workers, opts.Timeouts.Heartbeat, logger)
+	}
+
+	if opts.CacheCheck || opts.Mode == diagFull || len(opts.CacheObjects) > 0 {
+		report.CacheChecks = checkCache(cacheObjects(report.CDNs, opts.CacheObjects), servers, opts.Timeouts, logger)
+	}
+
+	if opts.DoHCheck || opts.Mode == diagFull {
+		report.DoH = checkDoH(opts.Workers, opts.Timeouts.DNS, logger)
+	}
+
+	if opts.InterceptionCheck || opts.Mode == diagFull {
+		interception := checkInterception(servers, opts.Workers, opts.Timeouts.DNS, logger)
+		report.Interception = &interception
+	}
+
+	report.Passed = true
+	for _, cdn := range report.CDNs {
+		if !cdn.Passed {
+			report.Passed = false
+		}
+	}
+	for _, check := range report.CacheChecks {
+		if check.Error == "" && !check.Effective && (opts.CacheCheck || len(opts.CacheObjects) > 0) {
+			report.Passed = false
+		}
+	}
+	for _, check := range report.SNI {
+		if !check.Passed && opts.SNICheck {
+			report.Passed = false
+		}
+	}
+
+	if report.Interception != nil && report.Interception.Detected {
+		_, _ = fmt.Fprintf(logger, "Warning: DNS interception detected, results per resolver may not come from the resolver that was queried\n")
+	}
+
+	if slices.Contains(opts.Formats, formatJSON) {
+		if err := writeJSONReport(jsonReport, report); err != nil {
+			_, _ = fmt.Fprint(logger, fmt.Errorf("error: failed to write json report %w", err))
+		}
 	}
+
+	return report
 }
 
-func simple(servers []string, logger io.Writer) {
+func simple(servers []string, opts Options, logger io.Writer) CDNReport {
 	_, _ = fmt.Fprintf(logger, "Looking up Steam diagnostics address...\n")
-	lookupHostnames(testHostname, nil, 6, servers, logger, nil, false)
+	return newCDNReport(steamDiagnosticsReport, lookupHostnames(testHostname, nil, opts.SimpleIterations, opts.Workers, opts.Timeouts, servers, logger, nil, false))
 }
 
-func full(servers []string, logger io.Writer, logfile *os.File) {
-	for _, cdn := range CDNs {
-		hostnames := parseCDN(cdn.Name, cdn.File, logger)
-		lookupHostnames("", hostnames, 1, servers, logger, logfile, true)
+func full(cdns []CDN, source *CacheDomainsSource, servers []string, opts Options, logger io.Writer, logfile *os.File) []CDNReport {
+	var reports []CDNReport
+	for _, cdn := range cdns {
+		hostnames := parseCDN(cdn, source, logger)
+		reports = append(reports, newCDNReport(cdn.Name, lookupHostnames("", hostnames, opts.Iterations, opts.Workers, opts.Timeouts, servers, logger, logfile, true)))
 	}
+	return reports
 }
 
-func custom(servers []string, logger io.Writer) {
-	var options []string
-	for _, cdn := range CDNs {
-		options = append(options, cdn.Name)
-	}
+func custom(cdns []CDN, source *CacheDomainsSource, servers []string, opts Options, logger io.Writer) []CDNReport {
+	selected := opts.CDNs
+	if len(selected) == 0 && len(opts.Hostnames) == 0 {
+		var options []string
+		for _, cdn := range cdns {
+			options = append(options, cdn.Name)
+		}
 
-	m := newModel("Select CDN(s):", options, true)
-	p := tea.NewProgram(&m)
-	fm, err := p.Run()
-	if err != nil {
-		_, _ = fmt.Fprint(logger, fmt.Errorf("error: prompt failed %w", err))
-		return
+		m := newModel("Select CDN(s):", options, true)
+		p := tea.NewProgram(&m)
+		fm, err := p.Run()
+		if err != nil {
+			_, _ = fmt.Fprint(logger, fmt.Errorf("error: prompt failed %w", err))
+			return []CDNReport{{Name: diagCustom, Error: err.Error()}}
+		}
+		selected = fm.(*Model).MultiSelected
 	}
 
-	for _, cdn := range fm.(*Model).MultiSelected {
-		for _, cdns := range CDNs {
-			if cdn == cdns.Name {
-				hostnames := parseCDN(cdn, cdns.File, logger)
-				lookupHostnames("", hostnames, 1, servers, logger, nil, false)
-			}
+	var reports []CDNReport
+	for _, name := range selected {
+		cdn, ok := findCDN(cdns, name)
+		if !ok {
+			_, _ = fmt.Fprintf(logger, "Unknown CDN: %s\n\n", name)
+			reports = append(reports, CDNReport{Name: name, Error: "unknown cdn"})
+			continue
 		}
+		hostnames := parseCDN(cdn, source, logger)
+		reports = append(reports, newCDNReport(cdn.Name, lookupHostnames("", hostnames, opts.Iterations, opts.Workers, opts.Timeouts, servers, logger, nil, false)))
 	}
+	return reports
 }
 
-func getInterfaceAddresses(logger io.Writer) {
+func getInterfaceAddresses(logger io.Writer) (result []Interface) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
 		_, _ = fmt.Fprint(logger, fmt.Errorf("error: %w", err))
-		return
+		return result
 	}
 
 	for _, i := range interfaces {
@@ -124,28 +317,46 @@ func getInterfaceAddresses(logger io.Writer) {
 		if strings.Contains(i.Flags.String(), running) {
 			if !strings.Contains(strings.ToLower(i.Name), loopback) && !strings.Contains(i.Flags.String(), loopback) {
 				_, _ = fmt.Fprintf(logger, "Interface: %s\n", i.Name)
+				iface := Interface{Name: i.Name}
 
 				for _, a := range addresses {
 					switch v := a.(type) {
 					case *net.IPAddr:
 						_, _ = fmt.Fprintf(logger, "IP Address: %s\n", v)
+						iface.Addresses = append(iface.Addresses, v.String())
 
 					case *net.IPNet:
 						_, _ = fmt.Fprintf(logger, "IP Address: %s\n", v)
+						iface.Addresses = append(iface.Addresses, v.String())
 					}
 				}
 				_, _ = fmt.Fprintf(logger, "\n")
+				result = append(result, iface)
 			}
 		}
 	}
+
+	return result
 }
 
-func lookupHostnames(host string, hostnames []string, iterations int, servers []string, logger io.Writer, logfile *os.File, debug bool) {
+func lookupHostnames(host string, hostnames []string, iterations, workers int, timeouts Timeouts, servers []string, logger io.Writer, logfile *os.File, debug bool) (reports []ResolverReport) {
 	var (
 		lookups, success, failed, deltas []Lookup
 	)
 
-	for _, resolver := range servers {
+	targets := hostnames
+	if host != "" {
+		targets = []string{host}
+	}
+
+	perResolver := iterations * len(targets)
+	results := make([]lookupResult, len(servers)*perResolver)
+	runPool(workers, len(results), func(i int) {
+		s, f, err := processHostnames(targets[i%perResolver%len(targets)], servers[i/perResolver], timeouts)
+		results[i] = lookupResult{success: s, failed: f, err: err}
+	})
+
+	for r, resolver := range servers {
 		success = nil
 		failed = nil
 		resolverMsg := "with system resolver"
@@ -153,18 +364,17 @@ func lookupHostnames(host string, hostnames []string, iterations int, servers []
 			resolverMsg = fmt.Sprintf("with resolver: %s", resolver)
 		}
 
-		for i := 0; i < iterations; i++ {
-			if host != "" {
-				s, f := processHostnames(host, resolver, logfile)
-				success = append(success, s...)
-				failed = append(failed, f...)
-			} else {
-				for _, hostname := range hostnames {
-					s, f := processHostnames(hostname, resolver, logfile)
-					success = append(success, s...)
-					failed = append(failed, f...)
-				}
+		for _, result := range results[r*perResolver : (r+1)*perResolver] {
+			if result.err != nil {
+				_, _ = fmt.Fprintf(logfile, "Could not get IPs: %v\n", result.err)
 			}
+			success = append(success, result.success...)
+			failed = append(failed, result.failed...)
+		}
+
+		warnings := lookupWarnings(resolver, failed)
+		for _, warning := range warnings {
+			_, _ = fmt.Fprintf(logger, "Warning: %s\n", warning)
 		}
 
 		unwrappedSuccess, unwrappedFail := unwrapLookups(success, failed)
@@ -172,90 +382,184 @@ func lookupHostnames(host string, hostnames []string, iterations int, servers []
 		if len(success) > 0 {
 			lookups = append(success, failed...)
 		} else {
-			_, _ = fmt.Fprintf(logger, "Unable to detect any LANCache instances %s\n\n", resolverMsg)
+			if slices.ContainsFunc(failed, func(l Lookup) bool { return l.ContainerID != "" }) {
+				_, _ = fmt.Fprintf(logger, "Detected LANCache instances %s, but not on every resolved address\n", resolverMsg)
+			} else {
+				_, _ = fmt.Fprintf(logger, "Unable to detect any LANCache instances %s\n", resolverMsg)
+			}
+			if reasons := countReasons(failed); reasons != nil {
+				_, _ = fmt.Fprintf(logger, "Failure reasons: %s\n", summariseReasons(reasons))
+			}
+			_, _ = fmt.Fprintf(logger, "\n")
 			if debug {
 				_, _ = fmt.Fprintf(logfile, "Successful lookups: %d\n"+
 					"%s"+
 					"\nFailed lookups: %d\n"+
 					"%s\n", len(success), unwrappedSuccess, len(failed), unwrappedFail)
 			}
+			reports = append(reports, newResolverReport(resolver, success, failed, warnings, false))
 			continue
 		}
 
 		deltas = isLookupInSliceEqual(lookups)
 		logOutput(host, resolverMsg, unwrappedSuccess, unwrappedFail, hostnames, iterations, lookups, success, failed, deltas, logger, logfile, debug)
+		reports = append(reports, newResolverReport(resolver, success, failed, warnings, len(deltas) == 0))
 	}
+
+	return reports
 }
 
-func processHostnames(hostname, resolver string, logfile *os.File) (success, failed []Lookup) {
-	ips, transport, err := resolveIP(hostname, resolver+portDNS)
+func processHostnames(hostname, resolver string, timeouts Timeouts) (success, failed []Lookup, err error) {
+	ips, answers, err := resolveIP(hostname, resolver, timeouts.DNS)
 	if err != nil {
-		_, _ = fmt.Fprintf(logfile, "Could not get IPs: %v\n", err)
 		failed = append(failed, Lookup{
 			Resolver: resolver,
 			Hostname: hostname,
-			Time:     time.Now().Format(time.RFC822),
+			Time:     time.Now().Format(time.RFC3339),
+			Reason:   classifyDNSError(err),
+			Error:    err.Error(),
+			DNS:      answers,
 		})
-		return success, failed
+		return success, failed, err
 	}
 
-	success, failed = lookupHeartbeat(hostname, resolver+portDNS, ips, transport)
+	success, failed = lookupHeartbeat(hostname, resolver, ips, timeouts.Heartbeat)
+
+	classes := classifyAddresses(ips)
+	for i := range success {
+		success[i].AddressClasses = classes
+		success[i].DNS = answers
+	}
+	for i := range failed {
+		failed[i].AddressClasses = classes
+		failed[i].DNS = answers
+		if onlyPublic(classes) {
+			failed[i].Reason = reasonPublicAddress
+		}
+	}
+
+	return success, failed, nil
+}
+
+func lookupHeartbeat(hostname, resolver string, ips []string, timeout time.Duration) (success, failed []Lookup) {
+	lookup := Lookup{
+		Resolver: resolver,
+		Hostname: hostname,
+		Address:  ips,
+	}
+
+	var (
+		v4, v6               []Probe
+		healthyV4, healthyV6 int
+		unhealthy            []string
+	)
+
+	for _, ip := range ips {
+		probe := probeHeartbeat(hostname, ip, timeout)
+		lookup.Probes = append(lookup.Probes, probe)
+
+		if probe.ContainerID == "" {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s %s", probe.Address, probe.Reason))
+		} else if lookup.ContainerID == "" {
+			lookup.ContainerID = probe.ContainerID
+		}
+
+		if net.ParseIP(ip).To4() != nil {
+			v4 = append(v4, probe)
+			if probe.ContainerID != "" {
+				healthyV4++
+			}
+		} else {
+			v6 = append(v6, probe)
+			if probe.ContainerID != "" {
+				healthyV6++
+			}
+		}
+	}
+	lookup.Time = time.Now().Format(time.RFC3339)
+
+	primary := v4
+	if len(primary) == 0 {
+		primary = v6
+	}
+
+	switch {
+	case healthyV4+healthyV6 == 0 || (len(v4) > 0 && healthyV4 == 0):
+		lookup.ContainerID = ""
+		lookup.Reason = primary[0].Reason
+		lookup.Error = primary[0].Error
+		failed = append(failed, lookup)
+	case len(v6) > 0 && healthyV6 == 0:
+		lookup.Reason = reasonIPv6Bypass
+		lookup.Error = strings.Join(unhealthy, ", ")
+		failed = append(failed, lookup)
+	case len(unhealthy) > 0:
+		lookup.Reason = reasonPartialCluster
+		lookup.Error = fmt.Sprintf("%d of %d addresses unhealthy: %s", len(unhealthy), len(ips), strings.Join(unhealthy, ", "))
+		failed = append(failed, lookup)
+	default:
+		lookup.Passed = true
+		success = append(success, lookup)
+	}
 
 	return success, failed
 }
 
-func lookupHeartbeat(hostname, resolver string, ips []string, transport *http.Transport) (success, failed []Lookup) {
+func probeHeartbeat(hostname, ip string, timeout time.Duration) Probe {
+	probe := Probe{Address: ip}
+
+	dialer := net.Dialer{
+		Timeout: timeout,
+	}
+	transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ip, portHTTP))
+	}}
+	defer transport.CloseIdleConnections()
+
 	client := &http.Client{
-		Timeout:   1 * time.Second,
+		Timeout:   timeout,
 		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
 	}
 
+	start := time.Now()
 	resp, err := client.Get(httpPrefix + hostname + heartbeatSuffix)
+	probe.Latency = milliseconds(time.Since(start))
 	if err != nil {
-		failed = append(failed, Lookup{
-			Resolver: resolver,
-			Hostname: hostname,
-			Address:  ips,
-			Time:     time.Now().Format(time.RFC822),
-		})
-		return success, failed
+		probe.Reason = classifyHTTPError(err)
+		probe.Error = err.Error()
+		return probe
 	}
+	_ = resp.Body.Close()
 
-	if resp.Header.Get(lancacheHeader) != "" {
-		success = append(success, Lookup{
-			Resolver:    resolver,
-			Hostname:    hostname,
-			Address:     ips,
-			ContainerID: resp.Header.Get(lancacheHeader),
-			Time:        time.Now().Format(time.RFC822),
-		})
-	} else {
-		failed = append(failed, Lookup{
-			Resolver: resolver,
-			Hostname: hostname,
-			Address:  ips,
-			Time:     time.Now().Format(time.RFC822),
-		})
+	probe.Reachable = true
+	probe.ContainerID = resp.Header.Get(lancacheHeader)
+	if probe.ContainerID == "" {
+		probe.Reason = reasonMissingHeader
+		probe.Error = "HTTP " + resp.Status
 	}
 
-	return success, failed
+	return probe
 }
 
-func parseCDN(name, file string, logger io.Writer) (hostnames []string) {
-	cdnHosts, err := urlToLines(cacheRepo+file, logger)
-	if err != nil {
-		_, _ = fmt.Fprint(logger, fmt.Errorf("error: failed to parse cdn file %w", err))
+func parseCDN(cdn CDN, source *CacheDomainsSource, logger io.Writer) (hostnames []string) {
+	var cdnHosts []string
+	for _, file := range cdn.Files {
+		lines, err := cacheDomainsLines(source, file, logger)
+		if err != nil {
+			_, _ = fmt.Fprint(logger, fmt.Errorf("error: failed to parse cdn file %w", err))
+		}
+		cdnHosts = append(cdnHosts, lines...)
 	}
 
 	_, _ = fmt.Fprintf(logger, "-----------------------------------------------------------------\n"+
 		"Looking up CDN: %s diagnostics addresses...\n"+
-		"-----------------------------------------------------------------\n", name)
+		"-----------------------------------------------------------------\n", cdn.Name)
 	for _, host := range cdnHosts {
 		host = strings.TrimSpace(host)
-		if strings.HasPrefix(host, "#") {
+		if host == "" || strings.HasPrefix(host, "#") {
 			continue
 		}
 		if strings.HasPrefix(host, "*.") {
@@ -267,37 +571,51 @@ func parseCDN(name, file string, logger io.Writer) (hostnames []string) {
 	return hostnames
 }
 
-func resolveIP(hostname, resolver string) ([]string, *http.Transport, error) {
+func resolveIP(hostname, resolver string, timeout time.Duration) ([]string, []DNSAnswer, error) {
 	var (
-		dialer net.Dialer
-		ips    []string
+		ips      []string
+		answers  []DNSAnswer
+		firstErr error
 	)
 
-	if resolver == systemResolver[0]+portDNS {
-		ips, err := net.LookupHost(hostname)
-		return ips, &http.Transport{}, err
-	}
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		var (
+			family []string
+			err    error
+		)
 
-	r := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			dialer = net.Dialer{
-				Timeout: 1 * time.Second,
+		if resolver == systemResolver[0] {
+			family, err = lookupSystem(hostname, qtype, timeout)
+		} else {
+			var answer DNSAnswer
+			family, answer, err = queryDNS(hostname, resolver, qtype, timeout)
+			answers = append(answers, answer)
+		}
+
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
 			}
-			return dialer.DialContext(ctx, network, resolver)
-		},
-	}
+			continue
+		}
 
-	ips, err := r.LookupHost(context.Background(), hostname)
+		slices.Sort(family)
+		ips = append(ips, family...)
+	}
 
-	transport := http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-		return dialer.DialContext(ctx, network, ips[0]+portHTTP)
-	}}
+	if len(ips) == 0 {
+		return nil, answers, firstErr
+	}
 
-	return ips, &transport, err
+	return ips, answers, nil
 }
 
 func logOutput(host, resolverMsg, unwrappedSuccess, unwrappedFail string, hostnames []string, iterations int, lookups, success, failed, deltas []Lookup, logger io.Writer, logfile *os.File, debug bool) {
+	reasons := "none"
+	if counts := countReasons(failed); counts != nil {
+		reasons = summariseReasons(counts)
+	}
+
 	if len(deltas) > 0 {
 		first := lookups[0]
 
@@ -307,16 +625,18 @@ func logOutput(host, resolverMsg, unwrappedSuccess, unwrappedFail string, hostna
 				"%s"+
 				"\nFailed lookups: %d\n"+
 				"%s"+
+				"\nFailure reasons: %s\n"+
 				"\nDidn't match:\n"+
-				"%+v\n\n", iterations, resolverMsg, len(success), unwrappedSuccess, len(failed), unwrappedFail, first)
+				"%+v\n\n", iterations, resolverMsg, len(success), unwrappedSuccess, len(failed), unwrappedFail, reasons, first)
 		} else {
 			_, _ = fmt.Fprintf(logger, "Unsuccessfully ran %d diagnostics iteration(s) on %d host(s) %s\n"+
 				"\nSuccessful lookups: %d\n"+
 				"%s"+
 				"\nFailed lookups: %d\n"+
 				"%s"+
+				"\nFailure reasons: %s\n"+
 				"\nDidn't match:\n"+
-				"%+v\n\n", iterations, len(hostnames), resolverMsg, len(success), unwrappedSuccess, len(failed), unwrappedFail, first)
+				"%+v\n\n", iterations, len(hostnames), resolverMsg, len(success), unwrappedSuccess, len(failed), unwrappedFail, reasons, first)
 		}
 	} else {
 		if host != "" {
@@ -328,7 +648,8 @@ func logOutput(host, resolverMsg, unwrappedSuccess, unwrappedFail string, hostna
 			_, _ = fmt.Fprintf(logfile, "Successful lookups: %d\n"+
 				"%s"+
 				"\nFailed lookups: %d\n"+
-				"%s\n", len(success), unwrappedSuccess, len(failed), unwrappedFail)
+				"%s"+
+				"\nFailure reasons: %s\n\n", len(success), unwrappedSuccess, len(failed), unwrappedFail, reasons)
 		}
 	}
 }
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"charm.land/bubbles/v2/table"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/huh/v2"
+)
+
+func buildMatrix(cdns []CDNReport, resolvers []string) *ResolverMatrix {
+	matrix := &ResolverMatrix{Resolvers: resolvers}
+	rows := map[string]int{}
+
+	for _, cdn := range cdns {
+		for _, resolver := range cdn.Resolvers {
+			column := slices.Index(resolvers, resolver.Resolver)
+			if column < 0 {
+				continue
+			}
+
+			for _, lookup := range resolver.Lookups {
+				row, ok := rows[lookup.Hostname]
+				if !ok {
+					row = len(matrix.Rows)
+					rows[lookup.Hostname] = row
+					matrix.Rows = append(matrix.Rows, MatrixRow{
+						Hostname: lookup.Hostname,
+						Cells:    make([]MatrixCell, len(resolvers)),
+					})
+				}
+
+				cell := &matrix.Rows[row].Cells[column]
+				for _, address := range lookup.Address {
+					if !slices.Contains(cell.Addresses, address) {
+						cell.Addresses = append(cell.Addresses, address)
+					}
+				}
+				for _, probe := range lookup.Probes {
+					if probe.ContainerID != "" && !slices.Contains(cell.ContainerIDs, probe.ContainerID) {
+						cell.ContainerIDs = append(cell.ContainerIDs, probe.ContainerID)
+					}
+				}
+				if len(lookup.Address) == 0 && lookup.Reason != "" {
+					cell.Reason = lookup.Reason
+				}
+			}
+		}
+	}
+
+	for i := range matrix.Rows {
+		compareRow(&matrix.Rows[i], resolvers)
+	}
+
+	return matrix
+}
+
+func compareRow(row *MatrixRow, resolvers []string) {
+	counts := map[string]int{}
+	var majority string
+	for i := range row.Cells {
+		slices.Sort(row.Cells[i].Addresses)
+		slices.Sort(row.Cells[i].ContainerIDs)
+
+		key := row.Cells[i].key()
+		counts[key]++
+		if i == 0 || counts[key] > counts[majority] {
+			majority = key
+		}
+	}
+
+	// Without a strict majority there is no way to tell which answer is
+	// right, so every resolver is marked.
+	if counts[majority] == len(row.Cells) {
+		row.Consistent = true
+		return
+	}
+	for i, cell := range row.Cells {
+		if cell.key() != majority || counts[majority]*2 <= len(row.Cells) {
+			row.Disagreeing = append(row.Disagreeing, resolvers[i])
+		}
+	}
+}
+
+// key leaves out the failure reason, so resolvers that both fail to return
+// an address agree regardless of how they failed.
+func (c MatrixCell) key() string {
+	return strings.Join(c.Addresses, ",") + "|" + strings.Join(c.ContainerIDs, ",")
+}
+
+func (c MatrixCell) String() string {
+	switch {
+	case len(c.Addresses) > 0 && len(c.ContainerIDs) > 0:
+		return fmt.Sprintf("%s [%s]", strings.Join(c.Addresses, ", "), strings.Join(c.ContainerIDs, ", "))
+	case len(c.Addresses) > 0:
+		return strings.Join(c.Addresses, ", ")
+	case c.Reason != "":
+		return string(c.Reason)
+	}
+	return "-"
+}
+
+func logMatrix(matrix *ResolverMatrix, logger io.Writer, logfile *os.File) {
+	consistent := 0
+	for _, row := range matrix.Rows {
+		if row.Consistent {
+			consistent++
+			continue
+		}
+
+		var cells []string
+		for i, cell := range row.Cells {
+			cells = append(cells, fmt.Sprintf("%s: %s", matrix.Resolvers[i], cell))
+		}
+		_, _ = fmt.Fprintf(logger, "Warning: resolvers disagree on %s, %s\n", row.Hostname, strings.Join(cells, "; "))
+	}
+	_, _ = fmt.Fprintf(logger, "Resolver consistency: %d of %d hostname(s) agree across %d resolver(s)\n\n",
+		consistent, len(matrix.Rows), len(matrix.Resolvers))
+
+	if logfile == nil {
+		return
+	}
+
+	w := tabwriter.NewWriter(logfile, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "\tHostname\t%s\n", strings.Join(matrix.Resolvers, "\t"))
+	for _, row := range matrix.Rows {
+		marker := ""
+		if !row.Consistent {
+			marker = "*"
+		}
+
+		var cells []string
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.String())
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", marker, row.Hostname, strings.Join(cells, "\t"))
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(logfile, "\n")
+}
+
+func showMatrix(matrix *ResolverMatrix) error {
+	m := newMatrixModel(matrix)
+	_, err := tea.NewProgram(&m).Run()
+	return err
+}
+
+func newMatrixModel(matrix *ResolverMatrix) MatrixModel {
+	columns := []table.Column{{Title: "Hostname", Width: 40}}
+	for _, resolver := range matrix.Resolvers {
+		columns = append(columns, table.Column{Title: resolver, Width: 32})
+	}
+	width := 0
+	for _, column := range columns {
+		width += column.Width + 2
+	}
+
+	m := MatrixModel{Matrix: matrix}
+	m.Table = table.New(
+		table.WithColumns(columns),
+		table.WithFocused(true),
+		table.WithHeight(min(len(matrix.Rows), 20)+1),
+		table.WithWidth(width),
+	)
+	m.Table.SetRows(m.rows())
+
+	return m
+}
+
+func (m *MatrixModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *MatrixModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			m.Quitting = true
+			return m, tea.Quit
+		case "d":
+			m.OnlyDisagreeing = !m.OnlyDisagreeing
+			m.Table.SetRows(m.rows())
+			m.Table.SetCursor(0)
+			return m, nil
+		}
+
+	case tea.WindowSizeMsg:
+		m.Table.SetHeight(max(min(msg.Height-5, len(m.Matrix.Rows)+1), 2))
+	}
+
+	var cmd tea.Cmd
+	m.Table, cmd = m.Table.Update(msg)
+	return m, cmd
+}
+
+func (m *MatrixModel) View() tea.View {
+	theme := huh.ThemeCharm(false)
+	title := theme.Focused.Base.Render() + theme.Focused.Title.Render(diagMatrix)
+
+	consistent := 0
+	for _, row := range m.Matrix.Rows {
+		if row.Consistent {
+			consistent++
+		}
+	}
+	status := fmt.Sprintf("%d of %d hostname(s) agree, disagreeing hostnames are marked with ≠", consistent, len(m.Matrix.Rows))
+
+	help := theme.Help
+	sep := help.ShortDesc.Render(" • ")
+	filter := "show only disagreeing"
+	if m.OnlyDisagreeing {
+		filter = "show all"
+	}
+	keys := strings.Join([]string{
+		help.ShortKey.Render("↑") + " " + help.ShortDesc.Render("up"),
+		help.ShortKey.Render("↓") + " " + help.ShortDesc.Render("down"),
+		help.ShortKey.Render("d") + " " + help.ShortDesc.Render(filter),
+		help.ShortKey.Render("esc") + " " + help.ShortDesc.Render("back"),
+	}, sep)
+
+	return tea.NewView(fmt.Sprintf(
+		"%s\n%s\n\n%s\n%s",
+		title,
+		m.Table.View(),
+		help.ShortDesc.Render(status),
+		keys,
+	))
+}
+
+func (m *MatrixModel) rows() []table.Row {
+	var rows []table.Row
+	for _, row := range m.Matrix.Rows {
+		if m.OnlyDisagreeing && row.Consistent {
+			continue
+		}
+
+		hostname := "  " + row.Hostname
+		if !row.Consistent {
+			hostname = "≠ " + row.Hostname
+		}
+
+		r := table.Row{hostname}
+		for _, cell := range row.Cells {
+			r = append(r, cell.String())
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
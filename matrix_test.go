@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareRow(t *testing.T) {
+	lancache := MatrixCell{Addresses: []string{"10.0.0.10"}, ContainerIDs: []string{"lancache-1"}}
+	upstream := MatrixCell{Addresses: []string{"203.0.113.10"}}
+
+	tests := []struct {
+		name        string
+		cells       []MatrixCell
+		consistent  bool
+		disagreeing []string
+	}{
+		{
+			name:       "all agree",
+			cells:      []MatrixCell{lancache, lancache, lancache},
+			consistent: true,
+		},
+		{
+			name:        "two against one",
+			cells:       []MatrixCell{lancache, upstream, lancache},
+			disagreeing: []string{"b"},
+		},
+		{
+			name:        "tie",
+			cells:       []MatrixCell{lancache, upstream},
+			disagreeing: []string{"a", "b"},
+		},
+		{
+			name:       "all fail",
+			cells:      []MatrixCell{{Reason: reasonDNSNXDomain}, {Reason: reasonDNSTimeout}, {Reason: reasonDNSError}},
+			consistent: true,
+		},
+		{
+			name:       "address order",
+			cells:      []MatrixCell{{Addresses: []string{"10.0.0.1", "10.0.0.2"}}, {Addresses: []string{"10.0.0.2", "10.0.0.1"}}},
+			consistent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := MatrixRow{Hostname: "lancache.steamcontent.com"}
+			for _, cell := range tt.cells {
+				cell.Addresses = slices.Clone(cell.Addresses)
+				row.Cells = append(row.Cells, cell)
+			}
+
+			compareRow(&row, []string{"a", "b", "c"}[:len(tt.cells)])
+
+			if row.Consistent != tt.consistent {
+				t.Errorf("Consistent = %v, want %v", row.Consistent, tt.consistent)
+			}
+			if !slices.Equal(row.Disagreeing, tt.disagreeing) {
+				t.Errorf("Disagreeing = %v, want %v", row.Disagreeing, tt.disagreeing)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/table"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/huh/v2"
+)
+
+func monitor(opts Options) error {
+	targets, err := optionTargets(opts)
+	if err != nil {
+		return err
+	}
+
+	m := newMonitorModel(targets, opts.Interval, opts.Workers, opts.Timeouts)
+	_, err = tea.NewProgram(&m).Run()
+	return err
+}
+
+func optionTargets(opts Options) ([]MonitorTarget, error) {
+	source := newCacheDomainsSource(opts.CacheDomains)
+	return monitorTargets(loadCDNs(source, io.Discard), opts.CDNs, opts.Hostnames, optionServers(opts), source)
+}
+
+func optionServers(opts Options) []string {
+	d, _, _ := discoverResolvers(opts.Nameservers)
+
+	policy := opts.ResolverPolicy
+	if policy == "" {
+		policy = policyAll
+	}
+	return selectResolvers(policy, d.Servers, opts.Resolvers)
+}
+
+func monitorTargets(cdns []CDN, names, hostnames, servers []string, source *CacheDomainsSource) ([]MonitorTarget, error) {
+	var hosts []MonitorTarget
+	add := func(cdn, hostname string) {
+		target := MonitorTarget{CDN: cdn, Hostname: hostname}
+		if !slices.Contains(hosts, target) {
+			hosts = append(hosts, target)
+		}
+	}
+	monitored := func(hostname string) bool {
+		return slices.ContainsFunc(hosts, func(host MonitorTarget) bool {
+			return host.Hostname == hostname
+		})
+	}
+
+	switch {
+	case len(names) == 0 && len(hostnames) > 0:
+		cdns = nil
+	case len(names) == 0:
+		add(steamDiagnosticsReport, testHostname)
+	default:
+		var selected []CDN
+		for _, name := range names {
+			cdn, ok := findCDN(cdns, name)
+			if !ok {
+				return nil, fmt.Errorf("unknown CDN %s", name)
+			}
+			selected = append(selected, cdn)
+		}
+		cdns = selected
+	}
+
+	for _, cdn := range cdns {
+		cdnHosts := parseCDN(cdn, source, io.Discard)
+		if len(cdnHosts) == 0 {
+			continue
+		}
+
+		// Prefer a hostname that is not monitored yet, such as the second
+		// Steam hostname next to the diagnostics address.
+		hostname := cdnHosts[0]
+		if i := slices.IndexFunc(cdnHosts, func(host string) bool { return !monitored(host) }); i >= 0 {
+			hostname = cdnHosts[i]
+		}
+		add(cdn.Name, hostname)
+	}
+	for _, hostname := range hostnames {
+		add(hostnamesReport, hostname)
+	}
+
+	var targets []MonitorTarget
+	for _, host := range hosts {
+		for _, server := range servers {
+			host.Resolver = server
+			targets = append(targets, host)
+		}
+	}
+
+	return targets, nil
+}
+
+func checkTargets(targets []MonitorTarget, workers int, timeouts Timeouts) []Lookup {
+	lookups := make([]Lookup, len(targets))
+	runPool(workers, len(targets), func(i int) {
+		success, failed, _ := processHostnames(targets[i].Hostname, targets[i].Resolver, timeouts)
+		lookups[i] = append(success, failed...)[0]
+	})
+	return lookups
+}
+
+func newMonitorModel(targets []MonitorTarget, interval time.Duration, workers int, timeouts Timeouts) MonitorModel {
+	m := MonitorModel{
+		Targets:  targets,
+		Statuses: make([]MonitorStatus, len(targets)),
+		Interval: interval,
+		Workers:  workers,
+		Timeouts: timeouts,
+	}
+	for i, target := range targets {
+		m.Statuses[i].Target = target
+	}
+
+	columns := []table.Column{
+		{Title: "CDN", Width: 18},
+		{Title: "Hostname", Width: 32},
+		{Title: "Resolver", Width: 24},
+		{Title: "Status", Width: 16},
+		{Title: "Latency", Width: 10},
+		{Title: "Container ID", Width: 14},
+		{Title: "Last Change", Width: 11},
+	}
+	width := 0
+	for _, column := range columns {
+		width += column.Width + 2
+	}
+
+	m.Table = table.New(
+		table.WithColumns(columns),
+		table.WithFocused(true),
+		table.WithHeight(len(targets)+1),
+		table.WithWidth(width),
+	)
+	m.Table.SetRows(m.rows())
+
+	return m
+}
+
+func (m *MonitorModel) Init() tea.Cmd {
+	return m.check()
+}
+
+func (m *MonitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			m.Quitting = true
+			return m, tea.Quit
+		}
+
+	case monitorResultMsg:
+		now := time.Now()
+		for i, lookup := range msg {
+			status := &m.Statuses[i]
+			if status.Checked.IsZero() || monitorState(status.Lookup) != monitorState(lookup) {
+				status.Changed = now
+			}
+			status.Lookup = lookup
+			status.Checked = now
+		}
+		m.Checking = false
+		m.Table.SetRows(m.rows())
+		return m, tea.Tick(m.Interval, func(time.Time) tea.Msg {
+			return monitorTickMsg{}
+		})
+
+	case monitorTickMsg:
+		return m, m.check()
+
+	case tea.WindowSizeMsg:
+		m.Table.SetHeight(max(min(msg.Height-5, len(m.Targets)+1), 2))
+	}
+
+	var cmd tea.Cmd
+	m.Table, cmd = m.Table.Update(msg)
+	return m, cmd
+}
+
+func (m *MonitorModel) View() tea.View {
+	theme := huh.ThemeCharm(false)
+	title := theme.Focused.Base.Render() + theme.Focused.Title.Render(diagMonitor)
+
+	status := fmt.Sprintf("Checking every %s", m.Interval)
+	if m.Checking {
+		status = "Checking..."
+	} else if len(m.Statuses) > 0 && !m.Statuses[0].Checked.IsZero() {
+		status = fmt.Sprintf("Last checked %s, checking every %s", m.Statuses[0].Checked.Format(time.TimeOnly), m.Interval)
+	}
+
+	help := theme.Help
+	sep := help.ShortDesc.Render(" • ")
+	keys := strings.Join([]string{
+		help.ShortKey.Render("↑") + " " + help.ShortDesc.Render("up"),
+		help.ShortKey.Render("↓") + " " + help.ShortDesc.Render("down"),
+		help.ShortKey.Render("esc") + " " + help.ShortDesc.Render("exit"),
+	}, sep)
+
+	return tea.NewView(fmt.Sprintf(
+		"%s\n%s\n\n%s\n%s",
+		title,
+		m.Table.View(),
+		help.ShortDesc.Render(status),
+		keys,
+	))
+}
+
+func (m *MonitorModel) check() tea.Cmd {
+	m.Checking = true
+	targets, workers, timeouts := m.Targets, m.Workers, m.Timeouts
+	return func() tea.Msg {
+		return monitorResultMsg(checkTargets(targets, workers, timeouts))
+	}
+}
+
+func (m *MonitorModel) rows() []table.Row {
+	var rows []table.Row
+	for _, status := range m.Statuses {
+		row := table.Row{status.Target.CDN, status.Target.Hostname, status.Target.Resolver, "pending", "-", "-", "-"}
+		if !status.Checked.IsZero() {
+			row[3] = monitorStatus(status.Lookup)
+			row[4] = monitorLatency(status.Lookup)
+			if status.Lookup.ContainerID != "" {
+				row[5] = status.Lookup.ContainerID
+			}
+			row[6] = status.Changed.Format(time.TimeOnly)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func monitorStatus(lookup Lookup) string {
+	if lookup.Passed {
+		return "passed"
+	}
+	return string(lookup.Reason)
+}
+
+func monitorState(lookup Lookup) string {
+	return monitorStatus(lookup) + " " + lookup.ContainerID
+}
+
+func monitorLatency(lookup Lookup) string {
+	var (
+		total   float64
+		reached int
+	)
+	for _, probe := range lookup.Probes {
+		if probe.Reachable {
+			total += probe.Latency
+			reached++
+		}
+	}
+	if reached == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f ms", total/float64(reached))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func loadProfiles(path string) ([]Profile, error) {
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(dir, configFile)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, profile := range config.Profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("%s: profile %d has no name", path, i+1)
+		}
+	}
+
+	return config.Profiles, nil
+}
+
+func findProfile(profiles []Profile, name string) (Profile, bool) {
+	for _, profile := range profiles {
+		if strings.EqualFold(profile.Name, name) {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+func applyProfile(opts Options, profile Profile) (Options, error) {
+	unset := func(flag string) bool {
+		return !opts.setFlags[flag]
+	}
+
+	if unset("mode") {
+		switch {
+		case profile.Mode != "":
+			opts.Mode = profile.Mode
+		case len(profile.CDNs) > 0:
+			opts.Mode = "custom"
+		case len(profile.Hostnames) > 0:
+			opts.Mode = "hostnames"
+		default:
+			opts.Mode = "simple"
+		}
+	}
+	if unset("cdn") && len(profile.CDNs) > 0 {
+		opts.CDNs = profile.CDNs
+	}
+	if unset("host") && len(profile.Hostnames) > 0 {
+		opts.Hostnames = profile.Hostnames
+	}
+	if unset("resolver") && len(profile.Resolvers) > 0 {
+		opts.Resolvers = profile.Resolvers
+	}
+	if unset("resolver-policy") && profile.ResolverPolicy != "" {
+		opts.ResolverPolicy = profile.ResolverPolicy
+	}
+	if unset("format") && len(profile.Formats) > 0 {
+		opts.Formats = profile.Formats
+	}
+	if unset("cache-object") && len(profile.CacheObjects) > 0 {
+		opts.CacheObjects = profile.CacheObjects
+	}
+	if unset("iterations") && profile.Iterations != 0 {
+		opts.Iterations = profile.Iterations
+	}
+	if unset("simple-iterations") && profile.SimpleIterations != 0 {
+		opts.SimpleIterations = profile.SimpleIterations
+	}
+
+	if unset("heartbeat-timeout") && profile.HeartbeatTimeout != "" {
+		d, err := time.ParseDuration(profile.HeartbeatTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("error: invalid heartbeat_timeout in profile %q: %w", profile.Name, err)
+		}
+		opts.Timeouts.Heartbeat = d
+	}
+	if unset("dns-timeout") && profile.DNSTimeout != "" {
+		d, err := time.ParseDuration(profile.DNSTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("error: invalid dns_timeout in profile %q: %w", profile.Name, err)
+		}
+		opts.Timeouts.DNS = d
+	}
+
+	opts, err := normaliseOptions(opts)
+	if err != nil {
+		return opts, fmt.Errorf("%w in profile %q", err, profile.Name)
+	}
+	return opts, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+func newResolverReport(resolver string, success, failed []Lookup, warnings []string, passed bool) ResolverReport {
+	return ResolverReport{
+		Resolver: resolver,
+		Passed:   passed,
+		Success:  len(success),
+		Failed:   len(failed),
+		Reasons:  countReasons(failed),
+		Warnings: warnings,
+		Lookups:  append(append([]Lookup{}, success...), failed...),
+	}
+}
+
+func newCDNReport(name string, resolvers []ResolverReport) CDNReport {
+	report := CDNReport{
+		Name:      name,
+		Passed:    len(resolvers) > 0,
+		Resolvers: resolvers,
+	}
+
+	for _, resolver := range resolvers {
+		if !resolver.Passed {
+			report.Passed = false
+		}
+	}
+
+	return report
+}
+
+func createTextReport() (io.Writer, *os.File) {
+	f, err := os.Create(textReport)
+	if err != nil {
+		_, _ = fmt.Fprint(os.Stdout, fmt.Errorf("error: %w", err))
+		return os.Stdout, nil
+	}
+	return io.MultiWriter(os.Stdout, f), f
+}
+
+func closeTextReport(f *os.File, logger io.Writer) {
+	if err := f.Close(); err != nil {
+		_, _ = fmt.Fprint(logger, fmt.Errorf("error: %w", err))
+	}
+}
+
+func writeJSONReport(path string, report Report) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
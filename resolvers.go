@@ -0,0 +1,27 @@
+package main
+
+import "slices"
+
+func selectResolvers(policy string, configured, custom []string) []string {
+	var servers []string
+
+	switch policy {
+	case policySystem:
+		servers = systemResolver
+	case policyConfigured:
+		servers = configured
+	case policyCustom:
+		servers = custom
+	case policyAll:
+		servers = append(append(append([]string{}, systemResolver...), configured...), custom...)
+	}
+
+	var selected []string
+	for _, server := range servers {
+		if !slices.Contains(selected, server) {
+			selected = append(selected, server)
+		}
+	}
+
+	return selected
+}
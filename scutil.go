@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+func parseScutilDNS(r io.Reader) ([]ScutilResolver, error) {
+	var (
+		resolvers []ScutilResolver
+		current   *ScutilResolver
+		scoped    bool
+	)
+
+	flush := func() {
+		if current != nil {
+			resolvers = append(resolvers, *current)
+			current = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "DNS configuration"):
+			flush()
+			scoped = strings.Contains(line, "scoped")
+			continue
+		case strings.HasPrefix(line, "resolver #"):
+			flush()
+			current = &ScutilResolver{Scoped: scoped}
+			continue
+		case current == nil:
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "domain":
+			current.Domain = value
+		case strings.HasPrefix(key, "search domain"):
+			current.Search = append(current.Search, value)
+		case strings.HasPrefix(key, "nameserver"):
+			current.Nameservers = append(current.Nameservers, value)
+		case key == "options":
+			current.Options = value
+		case key == "if_index":
+			if _, name, ok := strings.Cut(value, "("); ok {
+				current.Interface = strings.TrimSuffix(name, ")")
+			}
+		}
+	}
+	flush()
+
+	return resolvers, scanner.Err()
+}
+
+func scutilClientConfig(resolvers []ScutilResolver) (*dns.ClientConfig, []LinkDNS) {
+	config := &dns.ClientConfig{
+		Port:     portDNS,
+		Ndots:    1,
+		Timeout:  5,
+		Attempts: 2,
+	}
+
+	var links []LinkDNS
+	seen := map[string]bool{}
+
+	for _, resolver := range resolvers {
+		if len(resolver.Nameservers) == 0 || strings.Contains(resolver.Options, "mdns") {
+			continue
+		}
+
+		switch {
+		case resolver.Domain != "":
+			links = append(links, LinkDNS{
+				Interface: resolver.Interface,
+				Domain:    resolver.Domain,
+				Servers:   resolver.Nameservers,
+				Source:    sourceScutil,
+			})
+		case resolver.Scoped:
+			links = append(links, LinkDNS{
+				Interface: resolver.Interface,
+				Servers:   resolver.Nameservers,
+				Domains:   resolver.Search,
+				Source:    sourceScutil,
+			})
+		default:
+			if len(config.Search) == 0 {
+				config.Search = resolver.Search
+			}
+			for _, server := range resolver.Nameservers {
+				if !seen[server] {
+					seen[server] = true
+					config.Servers = append(config.Servers, server)
+				}
+			}
+		}
+	}
+
+	return config, links
+}
+
+func resolverDirLinks(dir string) []LinkDNS {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var links []LinkDNS
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		config, err := dns.ClientConfigFromFile(filepath.Join(dir, entry.Name()))
+		if err != nil || len(config.Servers) == 0 {
+			continue
+		}
+
+		links = append(links, LinkDNS{
+			Domain:  entry.Name(),
+			Servers: config.Servers,
+			Domains: config.Search,
+			Source:  filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	return links
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func loadScutilFixture(t *testing.T) []ScutilResolver {
+	t.Helper()
+
+	f, err := os.Open("testdata/scutil_dns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	resolvers, err := parseScutilDNS(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolvers
+}
+
+func TestParseScutilDNS(t *testing.T) {
+	want := []ScutilResolver{
+		{
+			Search:      []string{"lan", "corp.example.com"},
+			Nameservers: []string{"192.168.1.10", "fe80::1%en0"},
+			Interface:   "en0",
+		},
+		{
+			Domain:      "corp.example.com",
+			Nameservers: []string{"10.0.0.53"},
+			Interface:   "utun3",
+		},
+		{
+			Domain:  "local",
+			Options: "mdns",
+		},
+		{
+			Domain:  "254.169.in-addr.arpa",
+			Options: "mdns",
+		},
+		{
+			Search:      []string{"lan"},
+			Nameservers: []string{"192.168.1.10", "fe80::1%en0"},
+			Interface:   "en0",
+			Scoped:      true,
+		},
+		{
+			Nameservers: []string{"172.20.10.1"},
+			Interface:   "en7",
+			Scoped:      true,
+		},
+	}
+
+	if got := loadScutilFixture(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseScutilDNS() = %+v, want %+v", got, want)
+	}
+}
+
+func TestScutilClientConfig(t *testing.T) {
+	config, links := scutilClientConfig(loadScutilFixture(t))
+
+	if want := []string{"192.168.1.10", "fe80::1%en0"}; !reflect.DeepEqual(config.Servers, want) {
+		t.Errorf("Servers = %v, want %v", config.Servers, want)
+	}
+	if want := []string{"lan", "corp.example.com"}; !reflect.DeepEqual(config.Search, want) {
+		t.Errorf("Search = %v, want %v", config.Search, want)
+	}
+
+	want := []LinkDNS{
+		{Interface: "utun3", Domain: "corp.example.com", Servers: []string{"10.0.0.53"}, Source: sourceScutil},
+		{Interface: "en0", Servers: []string{"192.168.1.10", "fe80::1%en0"}, Domains: []string{"lan"}, Source: sourceScutil},
+		{Interface: "en7", Servers: []string{"172.20.10.1"}, Source: sourceScutil},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("links = %+v, want %+v", links, want)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+func checkSNI(cdns []CDNReport, workers int, timeout time.Duration, logger io.Writer) []SNICheck {
+	_, _ = fmt.Fprintf(logger, "Checking HTTPS passthrough on port %s...\n", portHTTPS)
+
+	var checks []SNICheck
+	seen := map[string]bool{}
+	for _, cdn := range cdns {
+		for _, resolver := range cdn.Resolvers {
+			for _, lookup := range resolver.Lookups {
+				// wildcard entries are rewritten to lancachetest. names that do not exist upstream
+				if strings.HasPrefix(lookup.Hostname, testPrefix) {
+					continue
+				}
+				for _, probe := range lookup.Probes {
+					key := lookup.Hostname + " " + probe.Address
+					if probe.ContainerID == "" || seen[key] {
+						continue
+					}
+					seen[key] = true
+					checks = append(checks, SNICheck{CDN: cdn.Name, Hostname: lookup.Hostname, Address: probe.Address})
+				}
+			}
+		}
+	}
+
+	runPool(workers, len(checks), func(i int) {
+		checks[i] = probeSNI(checks[i], timeout)
+	})
+
+	passed := 0
+	for _, check := range checks {
+		if check.Passed {
+			passed++
+			continue
+		}
+		_, _ = fmt.Fprintf(logger, "Warning: HTTPS for %s via %s is broken (%s: %s)\n", check.Hostname, check.Address, check.Reason, check.Error)
+	}
+	_, _ = fmt.Fprintf(logger, "HTTPS passthrough works for %d of %d host(s)\n\n", passed, len(checks))
+
+	return checks
+}
+
+func probeSNI(check SNICheck, timeout time.Duration) SNICheck {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    &tls.Config{ServerName: check.Hostname},
+	}
+
+	start := time.Now()
+	conn, err := dialer.Dial("tcp", net.JoinHostPort(check.Address, portHTTPS))
+	check.Latency = milliseconds(time.Since(start))
+	if err != nil {
+		check.Reason = classifyTLSError(err)
+		check.Error = err.Error()
+		return check
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) > 0 {
+		check.Subject = state.PeerCertificates[0].Subject.CommonName
+		check.Issuer = state.PeerCertificates[0].Issuer.CommonName
+	}
+	check.Passed = true
+
+	return check
+}
@@ -1,18 +1,294 @@
 package main
 
-import "charm.land/bubbles/v2/list"
+import (
+	"context"
+	"sync"
+	"time"
+
+	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/progress"
+	"charm.land/bubbles/v2/table"
+)
 
 type CDN struct {
-	Name string
-	File string
+	Key         string
+	Name        string
+	Description string
+	Files       []string
+}
+
+type CacheDomain struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	DomainFiles []string `json:"domain_files"`
+}
+
+type CacheDomainsSource struct {
+	Path    string
+	offline bool
+}
+
+type CacheDomains struct {
+	CacheDomains []CacheDomain `json:"cache_domains"`
 }
 
 type Lookup struct {
-	Resolver    string
-	Hostname    string
-	Address     []string
-	ContainerID string
-	Time        string
+	Resolver    string        `json:"resolver"`
+	Hostname    string        `json:"hostname"`
+	Address     []string      `json:"addresses"`
+	ContainerID string        `json:"container_id,omitempty"`
+	Time        string        `json:"time"`
+	Passed      bool          `json:"passed"`
+	Reason      FailureReason `json:"reason,omitempty"`
+	Error       string        `json:"error,omitempty"`
+
+	AddressClasses map[string]AddressClass `json:"address_classes,omitempty"`
+	Probes         []Probe                 `json:"probes,omitempty"`
+	DNS            []DNSAnswer             `json:"dns,omitempty"`
+}
+
+type DNSEndpoint struct {
+	Transport string
+	Host      string
+	Server    string
+}
+
+type DNSAnswer struct {
+	Type               string   `json:"type"`
+	Transport          string   `json:"transport"`
+	Server             string   `json:"server"`
+	Rcode              string   `json:"rcode,omitempty"`
+	Authoritative      bool     `json:"authoritative"`
+	RecursionAvailable bool     `json:"recursion_available"`
+	CNAMEs             []string `json:"cname_chain,omitempty"`
+	TTL                uint32   `json:"ttl"`
+	QueryTime          float64  `json:"query_time_ms"`
+}
+
+type Probe struct {
+	Address     string        `json:"address"`
+	Reachable   bool          `json:"reachable"`
+	ContainerID string        `json:"container_id,omitempty"`
+	Latency     float64       `json:"latency_ms"`
+	Reason      FailureReason `json:"reason,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+type AddressClass string
+
+type FailureReason string
+
+type Interface struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
+}
+
+type LinkDNS struct {
+	Interface string   `json:"interface,omitempty"`
+	Domain    string   `json:"domain,omitempty"`
+	Servers   []string `json:"servers"`
+	Domains   []string `json:"domains,omitempty"`
+	Source    string   `json:"source"`
+}
+
+type DiscoverySource struct {
+	Source  string   `json:"source"`
+	Servers []string `json:"servers,omitempty"`
+	Used    bool     `json:"used"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type ScutilResolver struct {
+	Domain      string
+	Search      []string
+	Nameservers []string
+	Interface   string
+	Options     string
+	Scoped      bool
+}
+
+type ResolverReport struct {
+	Resolver string                `json:"resolver"`
+	Passed   bool                  `json:"passed"`
+	Success  int                   `json:"success"`
+	Failed   int                   `json:"failed"`
+	Reasons  map[FailureReason]int `json:"reasons,omitempty"`
+	Warnings []string              `json:"warnings,omitempty"`
+	Lookups  []Lookup              `json:"lookups"`
+}
+
+type CDNReport struct {
+	Name      string           `json:"name"`
+	Passed    bool             `json:"passed"`
+	Error     string           `json:"error,omitempty"`
+	Resolvers []ResolverReport `json:"resolvers"`
+}
+
+type CacheCheck struct {
+	CDN         string `json:"cdn"`
+	URL         string `json:"url"`
+	Resolver    string `json:"resolver,omitempty"`
+	Address     string `json:"address,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+	First       string `json:"first_status,omitempty"`
+	Second      string `json:"second_status,omitempty"`
+	FirstMiss   bool   `json:"first_miss"`
+	Effective   bool   `json:"effective"`
+	Error       string `json:"error,omitempty"`
+}
+
+type SNICheck struct {
+	CDN      string        `json:"cdn"`
+	Hostname string        `json:"hostname"`
+	Address  string        `json:"address"`
+	Passed   bool          `json:"passed"`
+	Subject  string        `json:"subject,omitempty"`
+	Issuer   string        `json:"issuer,omitempty"`
+	Latency  float64       `json:"latency_ms"`
+	Reason   FailureReason `json:"reason,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type InterceptionResult struct {
+	Resolver        string   `json:"resolver"`
+	RandomRcode     string   `json:"random_rcode,omitempty"`
+	RandomAddresses []string `json:"random_addresses,omitempty"`
+	Whoami          []string `json:"whoami,omitempty"`
+	WhoamiRcode     string   `json:"whoami_rcode,omitempty"`
+	TestAddresses   []string `json:"test_addresses,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+type InterceptionReport struct {
+	RandomHostname string               `json:"random_hostname"`
+	Detected       bool                 `json:"detected"`
+	Findings       []string             `json:"findings,omitempty"`
+	Resolvers      []InterceptionResult `json:"resolvers"`
+}
+
+type MatrixCell struct {
+	Addresses    []string      `json:"addresses,omitempty"`
+	ContainerIDs []string      `json:"container_ids,omitempty"`
+	Reason       FailureReason `json:"reason,omitempty"`
+}
+
+type MatrixRow struct {
+	Hostname    string       `json:"hostname"`
+	Consistent  bool         `json:"consistent"`
+	Disagreeing []string     `json:"disagreeing,omitempty"`
+	Cells       []MatrixCell `json:"cells"`
+}
+
+type ResolverMatrix struct {
+	Resolvers []string    `json:"resolvers"`
+	Rows      []MatrixRow `json:"rows"`
+}
+
+type MatrixModel struct {
+	Table           table.Model
+	Matrix          *ResolverMatrix
+	OnlyDisagreeing bool
+	Quitting        bool
+}
+
+type DoHCheck struct {
+	Endpoint  string   `json:"endpoint"`
+	Reachable bool     `json:"reachable"`
+	Addresses []string `json:"addresses,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+type Report struct {
+	SchemaVersion int               `json:"schema_version"`
+	Mode          string            `json:"mode"`
+	Time          string            `json:"time"`
+	Passed        bool              `json:"passed"`
+	Interfaces    []Interface       `json:"interfaces"`
+	Resolvers     []string          `json:"resolvers"`
+	Links         []LinkDNS         `json:"links,omitempty"`
+	Discovery     []DiscoverySource `json:"discovery"`
+	Search        []string          `json:"search,omitempty"`
+	Ndots         int               `json:"ndots"`
+
+	ResolverPolicy  string   `json:"resolver_policy"`
+	TestedResolvers []string `json:"tested_resolvers"`
+	SteamResolvers  []string `json:"steam_resolvers,omitempty"`
+
+	HeartbeatTimeout float64 `json:"heartbeat_timeout_ms"`
+	DNSTimeout       float64 `json:"dns_timeout_ms"`
+	SimpleIterations int     `json:"simple_iterations,omitempty"`
+	Iterations       int     `json:"iterations,omitempty"`
+
+	CDNs        []CDNReport     `json:"cdns,omitempty"`
+	Matrix      *ResolverMatrix `json:"resolver_matrix,omitempty"`
+	CacheChecks []CacheCheck    `json:"cache_checks,omitempty"`
+	SNI         []SNICheck      `json:"sni,omitempty"`
+	DoH         []DoHCheck      `json:"doh,omitempty"`
+
+	Interception *InterceptionReport `json:"interception,omitempty"`
+	Benchmark    *BenchmarkResult    `json:"benchmark,omitempty"`
+}
+
+type Options struct {
+	Mode              string
+	CDNs              []string
+	Resolvers         []string
+	Formats           []string
+	Workers           int
+	CacheDomains      string
+	DoHCheck          bool
+	SNICheck          bool
+	CacheCheck        bool
+	InterceptionCheck bool
+
+	ResolverPolicy string
+	Nameservers    []string
+	Interval       time.Duration
+	Listen         string
+
+	Timeouts         Timeouts
+	Iterations       int
+	SimpleIterations int
+
+	Hostnames    []string
+	CacheObjects map[string]string
+	BenchURL     string
+	BenchCount   int
+	Profiles     []Profile
+	setFlags     map[string]bool
+}
+
+type Config struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+type Profile struct {
+	Name             string   `json:"name"`
+	Mode             string   `json:"mode,omitempty"`
+	CDNs             []string `json:"cdns,omitempty"`
+	Hostnames        []string `json:"hostnames,omitempty"`
+	Resolvers        []string `json:"resolvers,omitempty"`
+	ResolverPolicy   string   `json:"resolver_policy,omitempty"`
+	Formats          []string `json:"formats,omitempty"`
+	Iterations       int      `json:"iterations,omitempty"`
+	SimpleIterations int      `json:"simple_iterations,omitempty"`
+	HeartbeatTimeout string   `json:"heartbeat_timeout,omitempty"`
+	DNSTimeout       string   `json:"dns_timeout,omitempty"`
+
+	CacheObjects map[string]string `json:"cache_objects,omitempty"`
+}
+
+type Timeouts struct {
+	Heartbeat time.Duration
+	DNS       time.Duration
+}
+
+type listFlag []string
+
+type lookupResult struct {
+	success []Lookup
+	failed  []Lookup
+	err     error
 }
 
 type Item struct {
@@ -34,6 +310,85 @@ type Model struct {
 	Quitting       bool
 }
 
+type MonitorTarget struct {
+	CDN      string
+	Hostname string
+	Resolver string
+}
+
+type MonitorStatus struct {
+	Target  MonitorTarget
+	Lookup  Lookup
+	Checked time.Time
+	Changed time.Time
+}
+
+type MonitorModel struct {
+	Table    table.Model
+	Targets  []MonitorTarget
+	Statuses []MonitorStatus
+	Interval time.Duration
+	Workers  int
+	Timeouts Timeouts
+	Checking bool
+	Quitting bool
+}
+
+type Exporter struct {
+	Targets  []MonitorTarget
+	Workers  int
+	Timeouts Timeouts
+
+	mu       sync.Mutex
+	lookups  []Lookup
+	totals   []int
+	failures []map[FailureReason]int
+	checked  time.Time
+	duration time.Duration
+}
+
+type BenchmarkRun struct {
+	Bytes       int64   `json:"bytes"`
+	Duration    float64 `json:"duration_ms"`
+	TTFB        float64 `json:"ttfb_ms"`
+	Throughput  float64 `json:"mb_per_s"`
+	CacheStatus string  `json:"cache_status,omitempty"`
+}
+
+type BenchmarkResult struct {
+	URL         string         `json:"url"`
+	Resolver    string         `json:"resolver,omitempty"`
+	Address     string         `json:"address,omitempty"`
+	ContainerID string         `json:"container_id,omitempty"`
+	Runs        []BenchmarkRun `json:"runs"`
+	Throughput  float64        `json:"mb_per_s"`
+	StdDev      float64        `json:"mb_per_s_stddev"`
+	TTFB        float64        `json:"ttfb_ms"`
+	Error       string         `json:"error,omitempty"`
+}
+
+type BenchmarkModel struct {
+	Progress progress.Model
+	URL      string
+	Count    int
+	Run      int
+	Percent  float64
+	Quitting bool
+
+	cancel context.CancelFunc
+}
+
+type benchmarkProgressMsg struct {
+	Run      int
+	Fraction float64
+}
+
+type benchmarkDoneMsg struct{}
+
+type monitorResultMsg []Lookup
+
+type monitorTickMsg struct{}
+
 type Style struct {
 	Model *Model
 }
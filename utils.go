@@ -4,10 +4,35 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
+func runPool(workers, jobs int, fn func(i int)) {
+	var wg sync.WaitGroup
+
+	queue := make(chan int)
+	for range min(max(workers, 1), jobs) {
+		wg.Go(func() {
+			for i := range queue {
+				fn(i)
+			}
+		})
+	}
+
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+
+	wg.Wait()
+}
+
 func isLookupInSliceEqual(a []Lookup) []Lookup {
 	var l []Lookup
 
@@ -15,6 +40,8 @@ func isLookupInSliceEqual(a []Lookup) []Lookup {
 		original := v
 		v.Hostname = a[0].Hostname
 		v.Time = a[0].Time
+		v.Probes = a[0].Probes
+		v.DNS = a[0].DNS
 		if !reflect.DeepEqual(v, a[0]) {
 			l = append(l, original)
 		}
@@ -24,7 +51,60 @@ func isLookupInSliceEqual(a []Lookup) []Lookup {
 }
 
 func (l Lookup) String() string {
-	return fmt.Sprintf("%s %s %v", l.Hostname, l.Resolver, l.Address)
+	if len(l.Probes) == 0 {
+		return fmt.Sprintf("%s %s %v", l.Hostname, l.Resolver, l.Address) + dnsSummary(l.DNS)
+	}
+
+	var probes []string
+	for _, probe := range l.Probes {
+		if probe.ContainerID != "" {
+			probes = append(probes, fmt.Sprintf("%s (%s, %.1fms)", probe.Address, probe.ContainerID, probe.Latency))
+		} else {
+			probes = append(probes, fmt.Sprintf("%s (%s)", probe.Address, probe.Reason))
+		}
+	}
+
+	return fmt.Sprintf("%s %s [%s]", l.Hostname, l.Resolver, strings.Join(probes, " ")) + dnsSummary(l.DNS)
+}
+
+func (a DNSAnswer) String() string {
+	s := fmt.Sprintf("%s %s ttl=%d %.1fms @%s", a.Type, a.Rcode, a.TTL, a.QueryTime, a.Server)
+	if a.Rcode == "" {
+		s = fmt.Sprintf("%s no response @%s", a.Type, a.Server)
+	}
+	if a.Authoritative {
+		s += " aa"
+	}
+	if len(a.CNAMEs) > 0 {
+		s += " cname=" + strings.Join(a.CNAMEs, " -> ")
+	}
+	return s
+}
+
+func dnsSummary(answers []DNSAnswer) string {
+	if len(answers) == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, answer := range answers {
+		parts = append(parts, answer.String())
+	}
+	return " {" + strings.Join(parts, "; ") + "}"
+}
+
+func (l LinkDNS) Name() string {
+	switch {
+	case l.Domain != "" && l.Interface != "":
+		return fmt.Sprintf("domain %s on %s", l.Domain, l.Interface)
+	case l.Domain != "":
+		return "domain " + l.Domain
+	}
+	return l.Interface
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
 }
 
 func unwrapLookups(s, f []Lookup) (success, fail string) {
@@ -32,14 +112,19 @@ func unwrapLookups(s, f []Lookup) (success, fail string) {
 		success += "+" + lookup.String() + "\n"
 	}
 	for _, lookup := range f {
-		fail += "-" + lookup.String() + "\n"
+		fail += "-" + lookup.String()
+		if lookup.Reason != "" {
+			fail += fmt.Sprintf(" (%s: %s)", lookup.Reason, lookup.Error)
+		}
+		fail += "\n"
 	}
 
 	return success, fail
 }
 
 func urlToLines(url string, logger io.Writer) ([]string, error) {
-	resp, err := http.Get(url)
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -51,9 +136,26 @@ func urlToLines(url string, logger io.Writer) ([]string, error) {
 		}
 	}(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+
 	return linesFromReader(resp.Body)
 }
 
+func fileToLines(fsys fs.FS, name string) ([]string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(f fs.File) {
+		_ = f.Close()
+	}(f)
+
+	return linesFromReader(f)
+}
+
 func linesFromReader(r io.Reader) ([]string, error) {
 	var lines []string
 
@@ -67,3 +169,34 @@ func linesFromReader(r io.Reader) ([]string, error) {
 
 	return lines, nil
 }
+
+func readKeyValues(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, exists := values[key]; !exists {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return values, scanner.Err()
+}